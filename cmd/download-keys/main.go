@@ -0,0 +1,46 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cobratbq/goutils/assert"
+	"github.com/cobratbq/keysmap-tools/internal/keyring"
+	"github.com/cobratbq/keysmap-tools/internal/keyserver"
+)
+
+// download-keys downloads the keys for the key-ids on stdin from a keyserver into the cache
+// directory, which can then be used as keyring. The result is reported in the same format as
+// resolve-keyid.
+func main() {
+	destination := flag.String("d", "keys", "Cache directory for downloaded keys.")
+	server := flag.String("server", keyserver.DefaultURL, "Base URL of the HKP keyserver.")
+	negativeTTL := flag.Duration("negative-ttl", 24*time.Hour, "Duration for which a key-id that was not found, is not looked up again.")
+	flag.Parse()
+	cache, err := keyserver.OpenCache(*destination, *negativeTTL)
+	assert.Success(err, "Failed to open key cache: %+v")
+	client := keyserver.NewClient(*server)
+
+	var failures []string
+	for _, keyid := range keyring.ReadKeyIDs(bufio.NewReader(os.Stdin)) {
+		candidates, err := cache.Fetch(client, keyid)
+		if err != nil && err != keyserver.ErrNotFound {
+			os.Stderr.WriteString(fmt.Sprintf("Failed to download key %016X: %v\n", keyid, err))
+			failures = append(failures, fmt.Sprintf("%016X: %v", keyid, err))
+			continue
+		}
+		keyring.WriteResolution(os.Stdout, keyid, candidates)
+	}
+	if len(failures) > 0 {
+		os.Stderr.WriteString(fmt.Sprintf("Failed to download %d key(s):\n", len(failures)))
+		for _, failure := range failures {
+			os.Stderr.WriteString("  " + failure + "\n")
+		}
+		os.Exit(1)
+	}
+}
@@ -4,26 +4,60 @@ package main
 
 import (
 	"bufio"
+	"encoding/xml"
 	"flag"
+	"fmt"
 	"io"
+	"net/http"
 	"os"
-	"path"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/cobratbq/goutils/assert"
-	"github.com/cobratbq/goutils/std/net/http"
+	"github.com/cobratbq/keysmap-tools/internal/repository"
+	"github.com/cobratbq/keysmap-tools/internal/sbom"
 )
 
-const repositoryBaseURL = "https://repo1.maven.org/maven2/"
-
 var artifactPattern = regexp.MustCompile(`([a-zA-Z0-9\.\-_]+):([a-zA-Z0-9\.\-_]+)`)
 
+var groupPattern = regexp.MustCompile(`^[a-zA-Z0-9\.\-_]+$`)
+
 func main() {
 	destination := flag.String("d", "artifact-metadata", "Destination directory for artifact metadata.")
+	var repositories repository.Repositories
+	flag.Var(&repositories, "repo", "Repository base URL (http, https or file). Repeatable, tried in order. (default "+repository.DefaultURL+")")
+	retries := flag.Uint("retries", repository.DefaultRetryPolicy.Retries, "Number of retries for temporary download failures.")
+	checksums := flag.Bool("checksums", true, "Verify downloads against the strongest checksum published by the repository (sha512, sha256, sha1 or md5), and files from the local repository against the checksum files next to them.")
+	local := flag.String("local", "", "Local Maven repository, e.g. '~/.m2/repository', to read files from before downloading.")
+	sbomFile := flag.String("sbom", "", "SBOM (CycloneDX JSON, or SPDX JSON or tag-value) listing the artifacts, instead of artifacts on stdin.")
+	discover := flag.Bool("discover", false, "Read groupIDs from stdin and discover all artifacts of the groups, including subgroups, from the repository.")
 	flag.Parse()
+	policy := repository.DefaultRetryPolicy
+	policy.Retries = *retries
+	client := repository.NewClient(repositories, policy)
+	client.SetVerifyChecksums(*checksums)
+	if *local != "" {
+		assert.Success(client.SetLocal(*local), "Failed to use local repository: %+v")
+	}
 
+	var failures []string
+	if *sbomFile != "" {
+		artifacts, err := sbom.ReadFile(*sbomFile)
+		assert.Success(err, "Failed to read SBOM: %+v")
+		seen := make(map[string]struct{})
+		for _, artifact := range artifacts {
+			if _, ok := seen[artifact.GroupID+":"+artifact.ArtifactID]; ok {
+				continue
+			}
+			seen[artifact.GroupID+":"+artifact.ArtifactID] = struct{}{}
+			if err := downloadMetadata(client, *destination, artifact.GroupID, artifact.ArtifactID); err != nil {
+				failures = append(failures, artifact.GroupID+":"+artifact.ArtifactID+": "+err.Error())
+			}
+		}
+		reportFailures(failures)
+		return
+	}
 	reader := bufio.NewReader(os.Stdin)
 	var line string
 	var err error
@@ -36,6 +70,10 @@ func main() {
 		if strings.HasPrefix(line, "#") || line == "" {
 			continue
 		}
+		if *discover {
+			failures = append(failures, discoverGroup(client, *destination, line)...)
+			continue
+		}
 		matches := artifactPattern.FindStringSubmatch(line)
 		if matches == nil {
 			os.Stderr.WriteString("no match: " + line + "\n")
@@ -43,18 +81,119 @@ func main() {
 		}
 		groupID := matches[1]
 		artifactID := matches[2]
-		url := generateMetadataURL(groupID, artifactID)
-		destFile := filepath.Join(*destination, strings.Join([]string{groupID, ":", artifactID, ".xml"}, ""))
-		os.Stderr.WriteString("Downloading " + url + " ...\n")
-		_, err := http.DownloadToFilePath(destFile, url)
-		assert.Success(err, "Failed to download metadata for artifact "+groupID+":"+artifactID+": %+v")
+		if err := downloadMetadata(client, *destination, groupID, artifactID); err != nil {
+			failures = append(failures, groupID+":"+artifactID+": "+err.Error())
+		}
 	}
 	if err != io.EOF {
 		panic(err.Error())
 	}
+	reportFailures(failures)
+}
+
+// discoverGroup downloads the metadata of all artifacts discovered in the group.
+func discoverGroup(client *repository.Client, destination, groupID string) []string {
+	if !groupPattern.MatchString(groupID) {
+		os.Stderr.WriteString("no match: " + groupID + "\n")
+		return nil
+	}
+	os.Stderr.WriteString("Discovering artifacts of " + groupID + " ...\n")
+	artifacts, skipped, err := client.DiscoverArtifacts(groupID)
+	if err != nil {
+		os.Stderr.WriteString("  failed: " + err.Error() + "\n")
+		return []string{groupID + ": " + err.Error()}
+	}
+	os.Stderr.WriteString(fmt.Sprintf("  found %d artifact(s)\n", len(artifacts)))
+	var failures []string
+	for _, s := range skipped {
+		os.Stderr.WriteString("  skipped " + s + "\n")
+		failures = append(failures, groupID+": skipped "+s)
+	}
+	for _, artifact := range artifacts {
+		groupID, artifactID, _ := strings.Cut(artifact, ":")
+		if err := downloadMetadata(client, destination, groupID, artifactID); err != nil {
+			failures = append(failures, artifact+": "+err.Error())
+		}
+	}
+	return failures
 }
 
-func generateMetadataURL(groupID, artifactID string) string {
-	groupIDPath := path.Join(strings.Split(groupID, ".")...)
-	return repositoryBaseURL + path.Join(groupIDPath, artifactID, "maven-metadata.xml")
+// downloadMetadata downloads the metadata of an artifact, reporting progress to stderr. The
+// download is conditional on the metadata being modified since the previous download. An artifact
+// is reported as changed on stdout if its metadata was updated since the previous download.
+func downloadMetadata(client *repository.Client, destination, groupID, artifactID string) error {
+	relpath := repository.MetadataPath(groupID, artifactID)
+	destFile := filepath.Join(destination, strings.Join([]string{groupID, ":", artifactID, ".xml"}, ""))
+	previous, err := readMetadata(destFile)
+	var previousOutcome repository.Outcome
+	if err == nil {
+		// Without previous metadata, a conditional download would leave nothing to compare with.
+		previousOutcome, _ = repository.ReadOutcome(destFile)
+	}
+	os.Stderr.WriteString("Downloading " + relpath + " ...\n")
+	outcome, err := client.DownloadIfModified(destFile, relpath, previousOutcome)
+	if err != nil {
+		os.Stderr.WriteString("  failed: " + err.Error() + "\n")
+		return err
+	}
+	if err = repository.WriteOutcome(destFile, outcome); err != nil {
+		os.Stderr.WriteString("  failed to record download outcome: " + err.Error() + "\n")
+	}
+	if outcome.StatusCode == http.StatusNotModified {
+		os.Stderr.WriteString("  not modified\n")
+		return nil
+	}
+	os.Stderr.WriteString("  from " + outcome.URL + "\n")
+	if outcome.Checksum != "" {
+		os.Stderr.WriteString("  verified " + outcome.Checksum + " checksum\n")
+	}
+	current, err := readMetadata(destFile)
+	if err != nil {
+		os.Stderr.WriteString("  failed: " + err.Error() + "\n")
+		return err
+	}
+	if current.LastUpdated == previous.LastUpdated && previous.LastUpdated != "" {
+		return nil
+	}
+	if added := newVersions(previous.Versions, current.Versions); len(added) > 0 {
+		os.Stderr.WriteString("  new versions: " + strings.Join(added, ", ") + "\n")
+	}
+	os.Stdout.WriteString(groupID + ":" + artifactID + "\n")
+	return nil
+}
+
+func readMetadata(path string) (repository.Metadata, error) {
+	var metadata repository.Metadata
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return metadata, err
+	}
+	err = xml.Unmarshal(data, &metadata)
+	return metadata, err
+}
+
+// newVersions lists the versions in current that are not in previous.
+func newVersions(previous, current []string) []string {
+	known := make(map[string]struct{}, len(previous))
+	for _, v := range previous {
+		known[v] = struct{}{}
+	}
+	var added []string
+	for _, v := range current {
+		if _, ok := known[v]; !ok {
+			added = append(added, v)
+		}
+	}
+	return added
+}
+
+// reportFailures reports the failed downloads, if any, and exits with a failure status.
+func reportFailures(failures []string) {
+	if len(failures) > 0 {
+		os.Stderr.WriteString(fmt.Sprintf("Failed to download metadata for %d artifact(s):\n", len(failures)))
+		for _, failure := range failures {
+			os.Stderr.WriteString("  " + failure + "\n")
+		}
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,87 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/cobratbq/keysmap-tools/internal/keysmap"
+	"github.com/cobratbq/keysmap-tools/internal/repository"
+)
+
+// versionFilter selects the versions of an artifact to download signatures for. The metadata
+// provides the `latest` and `release` versions, if available. These are only used if listed in
+// versions, as stale metadata may refer to a version that is not (or no longer) available.
+type versionFilter func(versions []string, metadata *repository.Metadata) []string
+
+// parseVersionFilter parses a version filter: `latest`, `release`, `last:<N>` for the N highest
+// versions, or a Maven version range, e.g. `[2.0,)`. An empty filter selects all versions.
+func parseVersionFilter(spec string) (versionFilter, error) {
+	switch {
+	case spec == "":
+		return func(versions []string, _ *repository.Metadata) []string { return versions }, nil
+	case spec == "latest":
+		return func(versions []string, metadata *repository.Metadata) []string {
+			if metadata != nil && contains(versions, metadata.Latest) {
+				return []string{metadata.Latest}
+			}
+			return lastVersions(versions, 1, true)
+		}, nil
+	case spec == "release":
+		return func(versions []string, metadata *repository.Metadata) []string {
+			if metadata != nil && contains(versions, metadata.Release) {
+				return []string{metadata.Release}
+			}
+			return lastVersions(versions, 1, false)
+		}, nil
+	case strings.HasPrefix(spec, "last:"):
+		n, err := strconv.ParseUint(spec[len("last:"):], 10, 32)
+		if err != nil || n == 0 {
+			return nil, errors.New("invalid number of versions: " + spec)
+		}
+		return func(versions []string, _ *repository.Metadata) []string {
+			return lastVersions(versions, int(n), true)
+		}, nil
+	default:
+		r, err := keysmap.ParseVersionRange(spec)
+		if err != nil {
+			return nil, errors.New(err.Error() + ": " + spec)
+		}
+		return func(versions []string, _ *repository.Metadata) []string {
+			var selected []string
+			for _, v := range versions {
+				if r.Contains(v) {
+					selected = append(selected, v)
+				}
+			}
+			return selected
+		}, nil
+	}
+}
+
+// contains indicates whether version is one of versions.
+func contains(versions []string, version string) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// lastVersions returns the n highest versions, in ascending order. Snapshots are excluded unless
+// snapshots is true.
+func lastVersions(versions []string, n int, snapshots bool) []string {
+	var candidates []string
+	for _, v := range keysmap.OrderVersions(versions) {
+		if snapshots || !strings.HasSuffix(strings.ToUpper(v), "-SNAPSHOT") {
+			candidates = append(candidates, v)
+		}
+	}
+	if len(candidates) > n {
+		candidates = candidates[len(candidates)-n:]
+	}
+	return candidates
+}
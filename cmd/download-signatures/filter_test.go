@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cobratbq/keysmap-tools/internal/repository"
+)
+
+func TestVersionFilter(t *testing.T) {
+	versions := []string{"1.0", "2.0", "1.5", "2.1-SNAPSHOT", "2.0.1"}
+	metadata := repository.Metadata{Latest: "2.1-SNAPSHOT", Release: "2.0.1"}
+	stale := repository.Metadata{Latest: "3.0-SNAPSHOT", Release: "3.0"}
+	testvalues := []struct {
+		spec     string
+		metadata *repository.Metadata
+		expected []string
+	}{
+		{"", nil, versions},
+		{"latest", nil, []string{"2.1-SNAPSHOT"}},
+		{"release", nil, []string{"2.0.1"}},
+		{"release", &metadata, []string{"2.0.1"}},
+		{"latest", &stale, []string{"2.1-SNAPSHOT"}},
+		{"release", &stale, []string{"2.0.1"}},
+		{"last:2", nil, []string{"2.0.1", "2.1-SNAPSHOT"}},
+		{"[2.0,)", nil, []string{"2.0", "2.1-SNAPSHOT", "2.0.1"}},
+	}
+	for _, v := range testvalues {
+		filter, err := parseVersionFilter(v.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", v.spec, err)
+		}
+		if selected := filter(versions, v.metadata); !reflect.DeepEqual(selected, v.expected) {
+			t.Errorf("%s: expected %v, got %v", v.spec, v.expected, selected)
+		}
+	}
+	if _, err := parseVersionFilter("last:0"); err == nil {
+		t.Errorf("Expected error for invalid number of versions")
+	}
+}
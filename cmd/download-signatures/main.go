@@ -3,68 +3,236 @@
 package main
 
 import (
+	"bytes"
 	"encoding/xml"
-	"errors"
 	"flag"
-	"net/http"
+	"fmt"
 	"os"
-	"path"
+	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/cobratbq/goutils/assert"
 	io_ "github.com/cobratbq/goutils/std/io"
-	http_ "github.com/cobratbq/goutils/std/net/http"
-	os_ "github.com/cobratbq/goutils/std/os"
+	"github.com/cobratbq/keysmap-tools/internal/download"
+	"github.com/cobratbq/keysmap-tools/internal/repository"
+	"github.com/cobratbq/keysmap-tools/internal/sbom"
 )
 
 func main() {
 	destination := flag.String("d", "artifact-signatures", "The destination location for downloaded artifact signatures.")
+	workers := flag.Uint("j", 4, "Number of concurrent downloads.")
+	var repositories repository.Repositories
+	flag.Var(&repositories, "repo", "Repository base URL (http, https or file). Repeatable, tried in order. (default "+repository.DefaultURL+")")
+	retries := flag.Uint("retries", repository.DefaultRetryPolicy.Retries, "Number of retries for temporary download failures.")
+	checksums := flag.Bool("checksums", true, "Verify downloads against the strongest checksum published by the repository (sha512, sha256, sha1 or md5), and files from the local repository against the checksum files next to them.")
+	local := flag.String("local", "", "Local Maven repository, e.g. '~/.m2/repository', to read files from before downloading.")
+	classifiers := flag.String("classifiers", "", "Comma-separated list of classifiers, e.g. 'sources,javadoc', for which to additionally download signatures.")
+	sbomFile := flag.String("sbom", "", "SBOM (CycloneDX JSON, or SPDX JSON or tag-value) listing the artifact versions, instead of metadata on stdin.")
+	versions := flag.String("versions", "", "Filter for versions of each artifact: 'latest', 'release', 'last:<N>' or a Maven version range, e.g. '[2.0,)'.")
 	flag.Parse()
+	assert.Require(*workers > 0, "At least one worker is required for downloading signatures.")
+	filter, err := parseVersionFilter(*versions)
+	assert.Success(err, "Invalid version filter: %+v")
+	policy := repository.DefaultRetryPolicy
+	policy.Retries = *retries
+	client := repository.NewClient(repositories, policy)
+	client.SetVerifyChecksums(*checksums)
+	if *local != "" {
+		assert.Success(client.SetLocal(*local), "Failed to use local repository: %+v")
+	}
 
-	// TODO read metadata in separate goroutine and dump URLs on buffered channel.
-	data := io_.MustReadAll(os.Stdin)
-	var metadata metadata
-	xml.Unmarshal(data, &metadata)
-	for _, version := range metadata.Versions {
-		destinationPath := path.Join(*destination, generateName(metadata.GroupID, metadata.ArtifactID, version))
-		if _, err := os.Stat(destinationPath); err == nil {
-			// As artifact signatures are extremely unlikely to change, there
-			// is no sense in even thinking of downloading them again.
-			os.Stderr.WriteString("Skipping " + destinationPath + "\n")
-			continue
+	jobs := make(chan job, *workers)
+	results := make(chan result, *workers)
+	extraClassifiers := download.JarClassifiers(splitList(*classifiers))
+	go func() {
+		var list []job
+		var metadata *repository.Metadata
+		if *sbomFile != "" {
+			artifacts, err := sbom.ReadFile(*sbomFile)
+			assert.Success(err, "Failed to read SBOM: %+v")
+			list = sbomJobs(artifacts, extraClassifiers)
+		} else {
+			list, metadata = stdinJobs(io_.MustReadAll(os.Stdin), extraClassifiers)
+		}
+		for _, j := range filterJobs(list, filter, metadata) {
+			jobs <- j
 		}
-		url := generateURL(metadata.GroupID, metadata.ArtifactID, version)
-		os.Stderr.WriteString("Downloading " + url + " ...\n")
-		if code, err := http_.DownloadToFilePath(destinationPath, url); err != nil {
-			if errors.Is(err, http_.ErrStatusCode) && code != http.StatusNotFound {
-				// TODO how should we behave in case of HTTP status code 500?
-				panic("Failed to download " + destinationPath + ": " + err.Error())
+		close(jobs)
+	}()
+	var wg sync.WaitGroup
+	for i := uint(0); i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				report, failures := download.Version(client, *destination, j.extension, j.classifiers, j.groupID, j.artifactID, j.version)
+				results <- result{index: j.index, report: report, failures: failures}
 			}
-			// no need to panic if document is simply not found (404)
-			assert.Success(os_.CreateEmptyFile(destinationPath),
-				"Failed to create empty file "+destinationPath+": %+v")
-			os.Stderr.WriteString("  not found: " + url + "\n")
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	if failures := reportInOrder(results); len(failures) > 0 {
+		os.Stderr.WriteString(fmt.Sprintf("Failed to download %d signature(s):\n", len(failures)))
+		for _, failure := range failures {
+			os.Stderr.WriteString("  " + failure + "\n")
 		}
+		os.Exit(1)
 	}
 }
 
-func generateName(groupID, artifactID, version string) string {
-	return strings.Join([]string{groupID, ":", artifactID, ":", version, ".asc"}, "")
+type job struct {
+	index       int
+	groupID     string
+	artifactID  string
+	version     string
+	extension   string
+	classifiers []download.Classifier
 }
 
-type metadata struct {
-	GroupID     string   `xml:"groupId"`
-	ArtifactID  string   `xml:"artifactId"`
-	Latest      string   `xml:"versioning>latest"`
-	Release     string   `xml:"versioning>release"`
-	Versions    []string `xml:"versioning>versions>version"`
-	LastUpdated string   `xml:"versioning>lastUpdated"`
+// coordinatePattern matches `<groupID>:<artifactID>:<version>` lines.
+var coordinatePattern = regexp.MustCompile(`^([a-zA-Z0-9\.\-_]+):([a-zA-Z0-9\.\-_]+):([0-9a-zA-Z][0-9a-zA-Z\.\-\+_]*)$`)
+
+// stdinJobs creates jobs from either the artifact's metadata (`maven-metadata.xml`), for all of its
+// versions, or from `<groupID>:<artifactID>:<version>` lines. The metadata is returned if present.
+func stdinJobs(data []byte, classifiers []download.Classifier) ([]job, *repository.Metadata) {
+	var jobs []job
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		var metadata repository.Metadata
+		xml.Unmarshal(data, &metadata)
+		for i, version := range metadata.Versions {
+			jobs = append(jobs, job{index: i, groupID: metadata.GroupID, artifactID: metadata.ArtifactID,
+				version: version, classifiers: classifiers})
+		}
+		return jobs, &metadata
+	}
+	seen := make(map[string]struct{})
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		matches := coordinatePattern.FindStringSubmatch(line)
+		if matches == nil {
+			os.Stderr.WriteString("WARNING: Line does not match format: " + line + "\n")
+			continue
+		}
+		if _, ok := seen[line]; ok {
+			continue
+		}
+		seen[line] = struct{}{}
+		jobs = append(jobs, job{index: len(jobs), groupID: matches[1], artifactID: matches[2],
+			version: matches[3], classifiers: classifiers})
+	}
+	return jobs, nil
 }
 
-const repositoryBaseURL = "https://repo1.maven.org/maven2/"
+// filterJobs selects the jobs for the versions that pass the filter, applied to the versions of
+// each artifact separately. Jobs are re-indexed to remain consecutive.
+func filterJobs(jobs []job, filter versionFilter, metadata *repository.Metadata) []job {
+	versions := make(map[string][]string)
+	for _, j := range jobs {
+		versions[j.groupID+":"+j.artifactID] = append(versions[j.groupID+":"+j.artifactID], j.version)
+	}
+	selected := make(map[string]struct{})
+	for artifact, artifactVersions := range versions {
+		for _, v := range filter(artifactVersions, metadata) {
+			selected[artifact+":"+v] = struct{}{}
+		}
+	}
+	var filtered []job
+	for _, j := range jobs {
+		if _, ok := selected[download.Coordinate(j.groupID, j.artifactID, j.version)]; ok {
+			j.index = len(filtered)
+			filtered = append(filtered, j)
+		}
+	}
+	return filtered
+}
 
-func generateURL(groupID, artifactID, version string) string {
-	groupIDPath := path.Join(strings.Split(groupID, ".")...)
-	fileName := artifactID + "-" + version + ".jar.asc"
-	return repositoryBaseURL + path.Join(groupIDPath, artifactID, version, fileName)
+// sbomJobs creates a job for every version in the SBOM. The type of the unclassified artifact
+// determines the extension of the main artifact. Artifacts without version are skipped.
+func sbomJobs(artifacts []sbom.Artifact, extraClassifiers []download.Classifier) []job {
+	var jobs []job
+	indices := make(map[string]int)
+	for _, artifact := range artifacts {
+		if artifact.Version == "" {
+			os.Stderr.WriteString("WARNING: skipping artifact without version: " + artifact.GroupID + ":" + artifact.ArtifactID + "\n")
+			continue
+		}
+		key := download.Coordinate(artifact.GroupID, artifact.ArtifactID, artifact.Version)
+		i, ok := indices[key]
+		if !ok {
+			i = len(jobs)
+			indices[key] = i
+			jobs = append(jobs, job{index: i, groupID: artifact.GroupID, artifactID: artifact.ArtifactID,
+				version: artifact.Version, classifiers: append([]download.Classifier(nil), extraClassifiers...)})
+		}
+		extension := "jar"
+		if artifact.Type != "" {
+			extension = download.Extension(artifact.Type)
+		}
+		if artifact.Classifier == "" {
+			jobs[i].extension = extension
+			continue
+		}
+		classifier := download.Classifier{Name: artifact.Classifier, Extension: extension}
+		if !containsClassifier(jobs[i].classifiers, classifier) {
+			jobs[i].classifiers = append(jobs[i].classifiers, classifier)
+		}
+	}
+	return jobs
+}
+
+func containsClassifier(classifiers []download.Classifier, classifier download.Classifier) bool {
+	for _, c := range classifiers {
+		if c == classifier {
+			return true
+		}
+	}
+	return false
+}
+
+type result struct {
+	index    int
+	report   string
+	failures []string
+}
+
+// reportInOrder writes the reports of results to stderr in order of job index, such that output is
+// deterministic regardless of the order in which workers finish. It returns a record for every
+// failed download, in the same order.
+func reportInOrder(results <-chan result) []string {
+	pending := make(map[int]result)
+	next := 0
+	var failures []string
+	for r := range results {
+		pending[r.index] = r
+		for {
+			r, ok := pending[next]
+			if !ok {
+				break
+			}
+			os.Stderr.WriteString(r.report)
+			failures = append(failures, r.failures...)
+			delete(pending, next)
+			next++
+		}
+	}
+	assert.Require(len(pending) == 0, "BUG: expected all results to be reported.")
+	return failures
+}
+
+// splitList splits a comma-separated list, ignoring empty elements.
+func splitList(list string) []string {
+	var elements []string
+	for _, e := range strings.Split(list, ",") {
+		if e = strings.TrimSpace(e); e != "" {
+			elements = append(elements, e)
+		}
+	}
+	return elements
 }
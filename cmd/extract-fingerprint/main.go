@@ -3,30 +3,88 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"io"
 	"os"
+	"strconv"
+	"time"
 
-	"github.com/ProtonMail/go-crypto/openpgp/armor"
-	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/cobratbq/goutils/assert"
-	io_ "github.com/cobratbq/goutils/std/io"
+	"github.com/cobratbq/keysmap-tools/internal/keyring"
 )
 
+// main reads transferable public keys, either binary or any number of armored blocks, and writes
+// the primary key fingerprint of every key on a separate line, optionally followed by the primary
+// user ID. Every valid signing subkey follows on a separate line, prefixed with the primary key
+// fingerprint such that signatures by the subkey can be attributed to the primary key:
+//
+//	0x<primary fingerprint> [<primary user ID>]
+//	0x<primary fingerprint> subkey 0x<subkey fingerprint> <subkey key-id>
+//
+// In status mode, the status of the key (`valid`, `expired:<date>` or `revoked:<date>:<reason>`,
+// followed by the quoted revocation text if any) is written after the fingerprint. All
+// signing-capable subkeys are written, regardless of their status.
+//
+// Keys that cannot be read, e.g. because a subkey binding signature is invalid, are reported on
+// stderr as skipped.
 func main() {
-	block, err := armor.Decode(os.Stdin)
-	if err == io.EOF {
+	uid := flag.Bool("uid", false, "Output the primary user ID after the fingerprint.")
+	status := flag.Bool("status", false, "Output the status of keys and subkeys: valid, expired or revoked.")
+	valid := flag.Bool("valid", false, "Output only keys that are valid, i.e. not revoked or expired.")
+	flag.Parse()
+
+	// RSA, DSA, ECDSA, EdDSA (legacy, algorithm 22), Ed25519 and Ed448 (RFC 9580, algorithms 27 and
+	// 28) public keys are all supported. Self-signatures, subkey binding signatures and revocation
+	// signatures are verified.
+	keys, skipped, err := keyring.ReadKeysSkipped(os.Stdin)
+	for _, key := range skipped {
+		if key.Fingerprint == nil {
+			os.Stderr.WriteString("Skipping unreadable key: " + key.Err.Error() + "\n")
+		} else {
+			os.Stderr.WriteString(fmt.Sprintf("Skipping 0x%040X: %s\n", key.Fingerprint, key.Err))
+		}
+	}
+	if err == keyring.ErrNoKeys {
 		// do not silently accept that public key data is non-existent
 		os.Exit(1)
 	}
-	assert.Success(err, "failed to decode public key")
-	pkt, err := packet.NewReader(block.Body).Next()
-	assert.Success(err, "failed to read signature body")
-	switch key := pkt.(type) {
-	case *packet.PublicKey:
-		os.Stdout.WriteString(fmt.Sprintf("0x%040X", key.Fingerprint))
-	default:
-		panic(fmt.Sprintf("Unsupported type: %#v", key))
+	assert.Success(err, "failed to read public keys: %+v")
+	now := time.Now()
+	for _, key := range keys {
+		keyStatus := keyring.PrimaryKeyStatus(key, now)
+		if *valid && keyStatus.State != keyring.Valid {
+			os.Stderr.WriteString(fmt.Sprintf("Skipping 0x%040X: %s\n", key.PrimaryKey.Fingerprint, keyStatus))
+			continue
+		}
+		line := fmt.Sprintf("0x%040X", key.PrimaryKey.Fingerprint)
+		if *status {
+			line += formatStatus(keyStatus)
+		}
+		if identity := key.PrimaryIdentity(); *uid && identity != nil {
+			line += " " + identity.Name
+		}
+		os.Stdout.WriteString(line + "\n")
+		var subkeys []*openpgp.Subkey
+		if *status {
+			subkeys = keyring.SigningCapableSubkeys(key)
+		} else {
+			subkeys = keyring.SigningSubkeys(key, now)
+		}
+		for _, subkey := range subkeys {
+			line := fmt.Sprintf("0x%040X subkey 0x%040X %016X", key.PrimaryKey.Fingerprint,
+				subkey.PublicKey.Fingerprint, subkey.PublicKey.KeyId)
+			if *status {
+				line += formatStatus(keyring.SubkeyStatus(key, subkey, now))
+			}
+			os.Stdout.WriteString(line + "\n")
+		}
+	}
+}
+
+func formatStatus(status keyring.Status) string {
+	if status.ReasonText == "" {
+		return " " + status.String()
 	}
-	io_.Discard(block.Body)
+	return " " + status.String() + " " + strconv.Quote(status.ReasonText)
 }
@@ -0,0 +1,268 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/cobratbq/goutils/assert"
+	"github.com/cobratbq/keysmap-tools/internal/download"
+	"github.com/cobratbq/keysmap-tools/internal/keyring"
+	"github.com/cobratbq/keysmap-tools/internal/keysmap"
+	"github.com/cobratbq/keysmap-tools/internal/repository"
+	"github.com/cobratbq/keysmap-tools/internal/signature"
+)
+
+// coordinatePattern matches `<groupID>:<artifactID>`, for all versions of an artifact, or
+// `<groupID>:<artifactID>:<version>` for a single version.
+var coordinatePattern = regexp.MustCompile(`^([a-zA-Z0-9\.\-_]+):([a-zA-Z0-9\.\-_]+)(?::([0-9a-zA-Z][0-9a-zA-Z\.\-\+_]*))?$`)
+
+// generate-keysmap generates the keysmap for the artifacts listed on stdin, performing all stages
+// in-process: downloading metadata and signatures, extracting the issuers of signatures, resolving
+// these to primary key fingerprints using the keyring, and canonicalizing the keysmap. Downloaded
+// metadata and signatures are cached in the destination directory, in the same layout as
+// download-metadata and download-signatures.
+func main() {
+	destination := flag.String("d", "keysmap-cache", "Cache directory for downloaded metadata and signatures.")
+	keys := flag.String("k", "", "Keyring file or directory of key files.")
+	workers := flag.Uint("j", 4, "Number of concurrent downloads.")
+	var repositories repository.Repositories
+	flag.Var(&repositories, "repo", "Repository base URL (http, https or file). Repeatable, tried in order. (default "+repository.DefaultURL+")")
+	retries := flag.Uint("retries", repository.DefaultRetryPolicy.Retries, "Number of retries for temporary download failures.")
+	raw := flag.Bool("raw", false, "Output the keysmap entry of every version, instead of the canonicalized keysmap.")
+	flag.Parse()
+	assert.Require(*keys != "", "A keyring file or directory is required (-k).")
+	assert.Require(*workers > 0, "At least one worker is required for downloading signatures.")
+	entities, err := keyring.Load(*keys)
+	assert.Success(err, "Failed to load keys: %+v")
+	index := keyring.NewIndex(entities)
+	policy := repository.DefaultRetryPolicy
+	policy.Retries = *retries
+	client := repository.NewClient(repositories, policy)
+	metadataDir := filepath.Join(*destination, "metadata")
+	signaturesDir := filepath.Join(*destination, "signatures")
+	assert.Success(os.MkdirAll(metadataDir, 0755), "Failed to create metadata cache directory: %+v")
+	assert.Success(os.MkdirAll(signaturesDir, 0755), "Failed to create signatures cache directory: %+v")
+
+	jobs, failures := readCoordinates(client, metadataDir, bufio.NewReader(os.Stdin))
+	results := make([]result, len(jobs))
+	queue := make(chan int, *workers)
+	var wg sync.WaitGroup
+	for i := uint(0); i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				results[i] = process(client, signaturesDir, index, jobs[i])
+			}
+		}()
+	}
+	for i := range jobs {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	var lines bytes.Buffer
+	var unresolved []string
+	for _, r := range results {
+		os.Stderr.WriteString(r.report)
+		lines.WriteString(r.line)
+		unresolved = append(unresolved, r.unresolved...)
+		failures = append(failures, r.failures...)
+	}
+	if *raw {
+		_, err = os.Stdout.Write(lines.Bytes())
+		assert.Success(err, "Failed to write keysmap: %+v")
+	} else if lines.Len() > 0 {
+		entries, groups, identifiers := keysmap.Read(bufio.NewReader(&lines))
+		keysmap.Canonicalize(os.Stdout, entries, groups, identifiers)
+	}
+	if len(unresolved) > 0 {
+		os.Stderr.WriteString(fmt.Sprintf("Unresolved %d artifact version(s):\n", len(unresolved)))
+		for _, u := range unresolved {
+			os.Stderr.WriteString("  " + u + "\n")
+		}
+	}
+	if len(failures) > 0 {
+		os.Stderr.WriteString(fmt.Sprintf("Failed %d download(s):\n", len(failures)))
+		for _, failure := range failures {
+			os.Stderr.WriteString("  " + failure + "\n")
+		}
+		os.Exit(1)
+	}
+}
+
+type job struct {
+	groupID    string
+	artifactID string
+	version    string
+}
+
+type result struct {
+	report     string
+	line       string
+	unresolved []string
+	failures   []string
+}
+
+// readCoordinates reads the coordinates and expands artifacts into jobs for all their versions, as
+// listed in the artifact metadata. Duplicate versions are processed once.
+func readCoordinates(client *repository.Client, metadataDir string, reader *bufio.Reader) ([]job, []string) {
+	var jobs []job
+	var failures []string
+	seen := make(map[job]struct{})
+	add := func(j job) {
+		if _, ok := seen[j]; !ok {
+			seen[j] = struct{}{}
+			jobs = append(jobs, j)
+		}
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			break
+		}
+		assert.Success(err, "Unexpected failure reading line: %v")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		matches := coordinatePattern.FindStringSubmatch(line)
+		if matches == nil {
+			os.Stderr.WriteString("WARNING: Line does not match format: " + line + "\n")
+			continue
+		}
+		if matches[3] != "" {
+			add(job{groupID: matches[1], artifactID: matches[2], version: matches[3]})
+			continue
+		}
+		metadata, err := readMetadata(client, metadataDir, matches[1], matches[2])
+		if err != nil {
+			os.Stderr.WriteString("  failed: " + err.Error() + "\n")
+			failures = append(failures, matches[1]+":"+matches[2]+": "+err.Error())
+			continue
+		}
+		for _, version := range metadata.Versions {
+			add(job{groupID: matches[1], artifactID: matches[2], version: version})
+		}
+	}
+	return jobs, failures
+}
+
+// readMetadata downloads the metadata of an artifact, as metadata changes with every release.
+func readMetadata(client *repository.Client, metadataDir, groupID, artifactID string) (repository.Metadata, error) {
+	var metadata repository.Metadata
+	relpath := repository.MetadataPath(groupID, artifactID)
+	destination := filepath.Join(metadataDir, groupID+":"+artifactID+".xml")
+	os.Stderr.WriteString("Downloading " + relpath + " ...\n")
+	if _, err := client.Download(destination, relpath); err != nil {
+		return metadata, err
+	}
+	data, err := os.ReadFile(destination)
+	if err != nil {
+		return metadata, err
+	}
+	err = xml.Unmarshal(data, &metadata)
+	return metadata, err
+}
+
+// process downloads the signatures of a version and resolves the signer of the main artifact to
+// its keysmap entry.
+func process(client *repository.Client, signaturesDir string, index keyring.Index, j job) result {
+	coordinate := download.Coordinate(j.groupID, j.artifactID, j.version)
+	report, failures := download.Version(client, signaturesDir, "", nil, j.groupID, j.artifactID, j.version)
+	r := result{report: report, failures: failures}
+	content, err := os.ReadFile(download.SignaturePath(signaturesDir, j.groupID, j.artifactID, j.version))
+	if err != nil {
+		r.unresolved = append(r.unresolved, coordinate+": signature unavailable")
+		return r
+	}
+	issuers, err := signature.ReadIssuers(content)
+	if err == io.EOF {
+		r.line = coordinate + " = noSig\n"
+		return r
+	}
+	if err != nil {
+		r.unresolved = append(r.unresolved, coordinate+": unreadable signature: "+err.Error())
+		return r
+	}
+	var fingerprints, unknown, ambiguous []string
+	for _, issuer := range issuers {
+		candidates := resolve(index, issuer)
+		switch len(candidates) {
+		case 0:
+			unknown = append(unknown, fmt.Sprintf("%016X", issuer.KeyID))
+		case 1:
+			fingerprints = append(fingerprints, fmt.Sprintf("0x%040X", candidates[0].PrimaryKey.Fingerprint))
+		default:
+			ambiguous = append(ambiguous, fmt.Sprintf("%016X", issuer.KeyID))
+		}
+	}
+	var reasons []string
+	if len(ambiguous) > 0 {
+		reasons = append(reasons, "ambiguous key-id "+strings.Join(ambiguous, ", "))
+	}
+	switch {
+	case len(fingerprints) > 0 && !identical(fingerprints):
+		// A keysmap entry lists a single key per version, so the signers must agree on the key.
+		r.line = coordinate + " = noKey\n"
+		reasons = append(reasons, "signed by multiple keys "+strings.Join(fingerprints, ", "))
+	case len(fingerprints) > 0:
+		r.line = coordinate + " = " + fingerprints[0] + "\n"
+	case len(unknown) > 0:
+		r.line = coordinate + " = noKey\n"
+		reasons = append(reasons, "no key for key-id "+strings.Join(unknown, ", "))
+	default:
+		// Only ambiguous issuers: the key cannot be determined.
+		r.line = coordinate + " = noKey\n"
+	}
+	if len(reasons) > 0 {
+		r.unresolved = append(r.unresolved, coordinate+": "+strings.Join(reasons, "; "))
+	}
+	return r
+}
+
+// identical indicates whether all values are the same.
+func identical(values []string) bool {
+	for _, v := range values[1:] {
+		if v != values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve looks up the keys that may have issued a signature. If the signature identifies the
+// issuer by fingerprint, the fingerprint disambiguates key-id collisions.
+func resolve(index keyring.Index, issuer signature.Issuer) []*openpgp.Entity {
+	candidates := index.Lookup(issuer.KeyID)
+	if issuer.Fingerprint == nil || len(candidates) <= 1 {
+		return candidates
+	}
+	var matching []*openpgp.Entity
+	for _, candidate := range candidates {
+		if bytes.Equal(candidate.PrimaryKey.Fingerprint, issuer.Fingerprint) {
+			matching = append(matching, candidate)
+			continue
+		}
+		for _, subkey := range candidate.Subkeys {
+			if bytes.Equal(subkey.PublicKey.Fingerprint, issuer.Fingerprint) {
+				matching = append(matching, candidate)
+				break
+			}
+		}
+	}
+	return matching
+}
@@ -0,0 +1,100 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package main
+
+import (
+	"encoding/xml"
+	"flag"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cobratbq/goutils/assert"
+)
+
+// list-coordinates lists the coordinates of the dependencies, managed dependencies and plugins of
+// a POM, read from the file given as argument or from stdin. Versions are interpolated from the
+// POM's properties. A coordinate is listed without version if the version is absent, cannot be
+// resolved, or is a version range, such that all versions of the artifact are considered.
+func main() {
+	flag.Parse()
+	var data []byte
+	var err error
+	if flag.NArg() == 0 {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(flag.Arg(0))
+	}
+	assert.Success(err, "Failed to read POM: %+v")
+	var p pom
+	assert.Success(xml.Unmarshal(data, &p), "Failed to parse POM: %+v")
+	for _, c := range listCoordinates(&p) {
+		os.Stdout.WriteString(c.String() + "\n")
+	}
+}
+
+// listCoordinates lists the distinct coordinates in order of appearance. Dependencies and plugins
+// without version take the version from dependency management or plugin management.
+func listCoordinates(p *pom) []coordinate {
+	values := p.propertyValues()
+	managed := make(map[string]string)
+	for _, d := range p.DependencyManagement {
+		managed[d.GroupID+":"+d.ArtifactID] = d.Version
+	}
+	for _, d := range p.PluginManagement {
+		if d.GroupID == "" {
+			d.GroupID = defaultPluginGroupID
+		}
+		managed[d.GroupID+":"+d.ArtifactID] = d.Version
+	}
+	var coordinates []coordinate
+	seen := make(map[coordinate]struct{})
+	add := func(d dependency, defaultGroupID string) {
+		if d.GroupID == "" {
+			d.GroupID = defaultGroupID
+		}
+		if d.Version == "" {
+			d.Version = managed[d.GroupID+":"+d.ArtifactID]
+		}
+		c, ok := resolve(d, values)
+		if !ok {
+			return
+		}
+		if _, ok := seen[c]; !ok {
+			seen[c] = struct{}{}
+			coordinates = append(coordinates, c)
+		}
+	}
+	for _, d := range p.Dependencies {
+		add(d, "")
+	}
+	for _, d := range p.DependencyManagement {
+		add(d, "")
+	}
+	for _, d := range p.Plugins {
+		add(d, defaultPluginGroupID)
+	}
+	for _, d := range p.PluginManagement {
+		add(d, defaultPluginGroupID)
+	}
+	return coordinates
+}
+
+func resolve(d dependency, values map[string]string) (coordinate, bool) {
+	groupID, ok := interpolate(strings.TrimSpace(d.GroupID), values)
+	artifactID, ok2 := interpolate(strings.TrimSpace(d.ArtifactID), values)
+	if !ok || !ok2 || groupID == "" || artifactID == "" {
+		os.Stderr.WriteString("WARNING: skipping unresolvable coordinate: " + d.GroupID + ":" + d.ArtifactID + "\n")
+		return coordinate{}, false
+	}
+	c := coordinate{groupID: groupID, artifactID: artifactID}
+	version, ok := interpolate(strings.TrimSpace(d.Version), values)
+	if !ok {
+		os.Stderr.WriteString("WARNING: unresolvable version for " + c.String() + ": " + version + "\n")
+	} else if strings.ContainsAny(version, "[](),") {
+		os.Stderr.WriteString("WARNING: version range for " + c.String() + ": " + version + "\n")
+	} else {
+		c.version = version
+	}
+	return c, true
+}
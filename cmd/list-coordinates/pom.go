@@ -0,0 +1,109 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package main
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strings"
+)
+
+// defaultPluginGroupID is the groupId of plugins that do not specify their groupId.
+const defaultPluginGroupID = "org.apache.maven.plugins"
+
+// maxInterpolationDepth limits the nesting of property references, which also prevents cycles.
+const maxInterpolationDepth = 10
+
+var propertyReference = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+type pom struct {
+	GroupID              string       `xml:"groupId"`
+	ArtifactID           string       `xml:"artifactId"`
+	Version              string       `xml:"version"`
+	Parent               parent       `xml:"parent"`
+	Properties           properties   `xml:"properties"`
+	Dependencies         []dependency `xml:"dependencies>dependency"`
+	DependencyManagement []dependency `xml:"dependencyManagement>dependencies>dependency"`
+	Plugins              []dependency `xml:"build>plugins>plugin"`
+	PluginManagement     []dependency `xml:"build>pluginManagement>plugins>plugin"`
+}
+
+type parent struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+type properties struct {
+	Entries []property `xml:",any"`
+}
+
+type property struct {
+	XMLName xml.Name
+	Value   string `xml:",chardata"`
+}
+
+// dependency covers both dependencies and plugins, as only their coordinates are of interest.
+type dependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+// coordinate is an artifact with, if known, its version.
+type coordinate struct {
+	groupID    string
+	artifactID string
+	version    string
+}
+
+func (c coordinate) String() string {
+	if c.version == "" {
+		return c.groupID + ":" + c.artifactID
+	}
+	return c.groupID + ":" + c.artifactID + ":" + c.version
+}
+
+// propertyValues returns the properties available for interpolation: the POM's properties and the
+// project's coordinates, which are inherited from the parent if not specified.
+func (p *pom) propertyValues() map[string]string {
+	groupID, version := p.GroupID, p.Version
+	if groupID == "" {
+		groupID = p.Parent.GroupID
+	}
+	if version == "" {
+		version = p.Parent.Version
+	}
+	values := map[string]string{
+		"project.groupId":        groupID,
+		"project.artifactId":     p.ArtifactID,
+		"project.version":        version,
+		"project.parent.groupId": p.Parent.GroupID,
+		"project.parent.version": p.Parent.Version,
+		// deprecated forms that are still encountered
+		"pom.groupId":    groupID,
+		"pom.artifactId": p.ArtifactID,
+		"pom.version":    version,
+		"groupId":        groupID,
+		"artifactId":     p.ArtifactID,
+		"version":        version,
+	}
+	for _, entry := range p.Properties.Entries {
+		values[entry.XMLName.Local] = strings.TrimSpace(entry.Value)
+	}
+	return values
+}
+
+// interpolate replaces property references with their values. It reports false if any reference
+// cannot be resolved.
+func interpolate(value string, values map[string]string) (string, bool) {
+	for depth := 0; depth < maxInterpolationDepth && strings.Contains(value, "${"); depth++ {
+		value = propertyReference.ReplaceAllStringFunc(value, func(reference string) string {
+			if v, ok := values[reference[2:len(reference)-1]]; ok {
+				return v
+			}
+			return reference
+		})
+	}
+	return value, !strings.Contains(value, "${")
+}
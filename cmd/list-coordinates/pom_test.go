@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestListCoordinates(t *testing.T) {
+	data := []byte(`<project>
+  <parent><groupId>org.example</groupId><artifactId>parent</artifactId><version>1.0</version></parent>
+  <artifactId>app</artifactId>
+  <properties>
+    <lib.version>2.${lib.minor}</lib.version>
+    <lib.minor>5</lib.minor>
+  </properties>
+  <dependencies>
+    <dependency><groupId>org.example</groupId><artifactId>lib</artifactId><version>${lib.version}</version></dependency>
+    <dependency><groupId>${project.groupId}</groupId><artifactId>core</artifactId><version>${project.version}</version></dependency>
+    <dependency><groupId>org.example</groupId><artifactId>managed</artifactId></dependency>
+  </dependencies>
+  <dependencyManagement><dependencies>
+    <dependency><groupId>org.example</groupId><artifactId>managed</artifactId><version>3.0</version></dependency>
+    <dependency><groupId>org.example</groupId><artifactId>ranged</artifactId><version>[1.0,2.0)</version></dependency>
+  </dependencies></dependencyManagement>
+  <build><plugins>
+    <plugin><artifactId>maven-jar-plugin</artifactId><version>${undefined}</version></plugin>
+  </plugins></build>
+</project>`)
+	var p pom
+	if err := xml.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"org.example:lib:2.5",
+		"org.example:core:1.0",
+		"org.example:managed:3.0",
+		"org.example:ranged",
+		"org.apache.maven.plugins:maven-jar-plugin",
+	}
+	coordinates := listCoordinates(&p)
+	if len(coordinates) != len(expected) {
+		t.Fatalf("Expected %d coordinates, got: %v", len(expected), coordinates)
+	}
+	for i := range expected {
+		if coordinates[i].String() != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], coordinates[i].String())
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"net/mail"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/cobratbq/goutils/assert"
+	"github.com/cobratbq/keysmap-tools/internal/keyring"
+	"github.com/cobratbq/keysmap-tools/internal/keyserver"
+	"github.com/cobratbq/keysmap-tools/internal/signature"
+)
+
+// lookup-wkd looks up keys in the Web Key Directory of the email addresses of signers and
+// developers. Email addresses are taken from the signer's user ID in signature files and from the
+// `<developers>` in POM files, given as arguments, or read from stdin if there are no arguments.
+// The keys are written armored to stdout, such that they can be piped into extract-fingerprint.
+func main() {
+	destination := flag.String("d", "", "Key cache directory, as used by download-keys, to additionally store keys in.")
+	flag.Parse()
+	var cache *keyserver.Cache
+	if *destination != "" {
+		var err error
+		cache, err = keyserver.OpenCache(*destination, 0)
+		assert.Success(err, "Failed to open key cache: %+v")
+	}
+
+	var emails []string
+	if flag.NArg() == 0 {
+		emails = readEmails(bufio.NewReader(os.Stdin))
+	}
+	for _, name := range flag.Args() {
+		content, err := os.ReadFile(name)
+		assert.Success(err, "Failed to read file: %+v")
+		fileEmails, err := extractEmails(content)
+		if err != nil {
+			os.Stderr.WriteString("WARNING: no email addresses from " + name + ": " + err.Error() + "\n")
+			continue
+		}
+		emails = append(emails, fileEmails...)
+	}
+
+	wkd := keyserver.NewWKD(nil)
+	var keys openpgp.EntityList
+	seen := make(map[string]struct{})
+	var failures []string
+	for _, email := range emails {
+		if _, ok := seen[strings.ToLower(email)]; ok {
+			continue
+		}
+		seen[strings.ToLower(email)] = struct{}{}
+		os.Stderr.WriteString("Looking up " + email + " ...\n")
+		found, err := wkd.Lookup(email)
+		if err == keyserver.ErrNotFound || err == keyserver.ErrInvalidEmail {
+			os.Stderr.WriteString("  " + err.Error() + "\n")
+			continue
+		}
+		if err != nil {
+			os.Stderr.WriteString("  failed: " + err.Error() + "\n")
+			failures = append(failures, email+": "+err.Error())
+			continue
+		}
+		for _, key := range found {
+			os.Stderr.WriteString(fmt.Sprintf("  found 0x%040X\n", key.PrimaryKey.Fingerprint))
+			if cache != nil {
+				assert.Success(cache.Store(key), "Failed to store key in cache: %+v")
+			}
+		}
+		keys = append(keys, found...)
+	}
+	if len(keys) > 0 {
+		assert.Success(keyring.WriteKeys(os.Stdout, keys), "Failed to write keys: %+v")
+	}
+	if len(failures) > 0 {
+		os.Stderr.WriteString(fmt.Sprintf("Failed to look up %d email address(es):\n", len(failures)))
+		for _, failure := range failures {
+			os.Stderr.WriteString("  " + failure + "\n")
+		}
+		os.Exit(1)
+	}
+}
+
+func readEmails(reader *bufio.Reader) []string {
+	var emails []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			break
+		}
+		assert.Success(err, "Unexpected failure reading line: %v")
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		emails = append(emails, line)
+	}
+	return emails
+}
+
+type pom struct {
+	Developers []string `xml:"developers>developer>email"`
+}
+
+// extractEmails extracts the email addresses from either a signature, from the signers' user IDs,
+// or from a POM, from its developers.
+func extractEmails(content []byte) ([]string, error) {
+	var emails []string
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("-----BEGIN PGP SIGNATURE-----")) {
+		issuers, err := signature.ReadIssuers(content)
+		if err != nil {
+			return nil, err
+		}
+		for _, issuer := range issuers {
+			if issuer.SignerUserID == "" {
+				continue
+			}
+			if address, err := mail.ParseAddress(issuer.SignerUserID); err == nil {
+				emails = append(emails, address.Address)
+			} else if strings.Contains(issuer.SignerUserID, "@") {
+				emails = append(emails, issuer.SignerUserID)
+			}
+		}
+		return emails, nil
+	}
+	var p pom
+	if err := xml.Unmarshal(content, &p); err != nil {
+		return nil, err
+	}
+	for _, email := range p.Developers {
+		if email = strings.TrimSpace(email); email != "" {
+			emails = append(emails, email)
+		}
+	}
+	return emails, nil
+}
@@ -0,0 +1,26 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"os"
+
+	"github.com/cobratbq/goutils/assert"
+	"github.com/cobratbq/keysmap-tools/internal/keyring"
+)
+
+func main() {
+	keys := flag.String("k", "", "Keyring file or directory of key files.")
+	flag.Parse()
+	assert.Require(*keys != "", "A keyring file or directory is required (-k).")
+
+	entities, err := keyring.Load(*keys)
+	assert.Success(err, "Failed to load keys: %+v")
+	index := keyring.NewIndex(entities)
+
+	for _, keyid := range keyring.ReadKeyIDs(bufio.NewReader(os.Stdin)) {
+		keyring.WriteResolution(os.Stdout, keyid, index.Lookup(keyid))
+	}
+}
@@ -0,0 +1,83 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// needsEscape indicates whether a file name must be escaped in a checksum line. An escaped file
+// name is marked by a backslash at the start of the checksum line.
+func needsEscape(name string) bool {
+	return strings.ContainsAny(name, "\\\n\r")
+}
+
+var escaper = strings.NewReplacer("\\", "\\\\", "\n", "\\n", "\r", "\\r")
+
+// escape escapes backslash, newline and carriage return, as coreutils does.
+func escape(name string) string {
+	return escaper.Replace(name)
+}
+
+// unescape reverses escape. unescape fails on any other (or incomplete) escape sequence.
+func unescape(name string) (string, bool) {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '\\' {
+			b.WriteByte(name[i])
+			continue
+		}
+		if i++; i == len(name) {
+			return "", false
+		}
+		switch name[i] {
+		case '\\':
+			b.WriteByte('\\')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// quote quotes a file name for use in diagnostic messages, in the manner of the shell-escape
+// quoting of coreutils: names without shell-special characters, or colons, are left as-is. Names
+// containing a single quote are double-quoted if possible. Control characters are written as `$'\n'`.
+func quote(name string) string {
+	if name != "" && name != "{" && name != "}" && !strings.ContainsAny(name, " !\"$&'()*:;<=>?[\\^`|") &&
+		!containsControl(name) && !strings.HasPrefix(name, "#") && !strings.HasPrefix(name, "~") {
+		return name
+	}
+	if strings.Contains(name, "'") && !strings.ContainsAny(name, "\"$`\\") && !containsControl(name) {
+		return "\"" + name + "\""
+	}
+	var b strings.Builder
+	b.WriteByte('\'')
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c == '\'':
+			b.WriteString("'\\''")
+		case c == '\t':
+			b.WriteString("'$'\\t''")
+		case c == '\n':
+			b.WriteString("'$'\\n''")
+		case c == '\r':
+			b.WriteString("'$'\\r''")
+		case c < 0x20 || c == 0x7f:
+			b.WriteString(fmt.Sprintf("'$'\\%03o''", c))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+func containsControl(name string) bool {
+	return strings.IndexFunc(name, func(r rune) bool { return r < 0x20 || r == 0x7f }) >= 0
+}
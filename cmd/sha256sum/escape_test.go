@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestEscape(t *testing.T) {
+	cases := map[string]string{
+		"plain": "plain",
+		"x\\y":  "x\\\\y",
+		"l\nm":  "l\\nm",
+		"c\rd":  "c\\rd",
+	}
+	for name, expected := range cases {
+		if needsEscape(name) != (name != expected) {
+			t.Errorf("Unexpected need for escaping %q", name)
+		}
+		if escaped := escape(name); escaped != expected {
+			t.Errorf("Expected %q to escape to %q, got %q", name, expected, escaped)
+		}
+		if unescaped, ok := unescape(expected); !ok || unescaped != name {
+			t.Errorf("Expected %q to unescape to %q, got %q", expected, name, unescaped)
+		}
+	}
+	if _, ok := unescape("x\\q"); ok {
+		t.Error("Expected unknown escape sequence to fail.")
+	}
+	if _, ok := unescape("x\\"); ok {
+		t.Error("Expected incomplete escape sequence to fail.")
+	}
+}
+
+func TestQuote(t *testing.T) {
+	cases := map[string]string{
+		"missing":    "missing",
+		"a b":        "'a b'",
+		"it's":       "\"it's\"",
+		"it's $HOME": "'it'\\''s $HOME'",
+		"dir/file-1": "dir/file-1",
+		" l\nm":      "' l'$'\\n''m'",
+	}
+	for name, expected := range cases {
+		if actual := quote(name); actual != expected {
+			t.Errorf("Expected %q to be quoted as %s, got %s", name, expected, actual)
+		}
+	}
+}
@@ -4,68 +4,52 @@ package main
 
 import (
 	"bufio"
-	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/cobratbq/goutils/assert"
 	io_ "github.com/cobratbq/goutils/std/io"
+	checksum_ "github.com/cobratbq/keysmap-tools/internal/checksum"
 )
 
-// TODO consider if we should change error handling tactics, as we silence the original error now - in favor of our own error.
+// program is the name used in messages, which corresponds to the default algorithm.
+var program = "sha256sum"
+
 func main() {
 	config := initConfig()
 	if err := verifyConfig(config); err != nil {
+		os.Stderr.WriteString("Try '" + program + " --help' for more information.\n")
 		os.Exit(1)
 	}
 
 	exitCode := 0
 	if config.checkMode {
 		// check existing checksum files
-		failures := uint(0)
 		for _, source := range config.sources {
-			sourceFails, err := verifySource(config, source)
-			if err != nil {
-				if err == os.ErrNotExist {
-					os.Stderr.WriteString("sha256sum: " + source + ": No such file or directory\n")
-				} else if err == os.ErrInvalid {
-					os.Stderr.WriteString("sha256sum: " + source + ": read error\n")
-				} else if err == io.ErrNoProgress {
-					os.Stderr.WriteString("sha256sum: " + source + ": no properly formatted SHA256 checksum lines found\n")
-				} else {
-					panic("Unexpected failure verifying source '" + source + "': " + err.Error())
-				}
+			if !verifySource(config, source) {
 				exitCode = 1
-				continue
 			}
-			failures += sourceFails
-		}
-		if failures > 0 {
-			exitCode = 1
-			os.Stderr.WriteString(fmt.Sprintf("sha256sum: WARNING: %d computed checksum(s) did NOT match\n", failures))
 		}
 	} else {
 		// generate checksum content, given provided inputs
-		var err error
-		var sum []byte
 		for _, source := range config.sources {
-			if sum, err = checksumSource(os.Stdout, source); err != nil {
-				if err == os.ErrNotExist {
-					os.Stderr.WriteString("sha256sum: " + source + ": No such file or directory\n")
-				} else if err == os.ErrInvalid {
-					os.Stderr.WriteString("sha256sum: " + source + ": Is a directory\n")
-				} else {
-					os.Stderr.WriteString("sha256sum: " + err.Error() + "\n")
-				}
+			sum, err := checksumFile(config.algorithm, source)
+			if err != nil {
+				writeError(source, err)
 				exitCode = 1
 				continue
 			}
-			assert.Success(err, "Unexpected failure reading content from '"+source+"': %v")
-			writeChecksum(os.Stdout, sum, source)
+			writeChecksum(os.Stdout, config, sum, source)
 		}
 	}
 	os.Exit(exitCode)
@@ -74,132 +58,318 @@ func main() {
 type config struct {
 	checkMode bool
 	sources   []string
-	quiet     bool
+	algorithm checksum_.Algorithm
+	// detect indicates that, in check mode, the algorithm is detected by the digest length.
+	detect bool
+	// binary and text indicate whether binary or text mode was explicitly selected.
+	binary bool
+	text   bool
+	zero   bool
+	// reporting is the last of the options quiet, status and warn, if any.
+	reporting     string
+	strict        bool
+	ignoreMissing bool
+	// layout is the layout of checksum lines, which coreutils determines once for all sources.
+	layout lineLayout
+}
+
+// reportingFlag sets the reporting level. As in coreutils, the options that set the reporting level
+// override each other, such that the last one takes effect.
+type reportingFlag struct {
+	reporting *string
+	level     string
+}
+
+func (f reportingFlag) String() string {
+	return ""
+}
+
+func (f reportingFlag) Set(value string) error {
+	set, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if set {
+		*f.reporting = f.level
+	} else if *f.reporting == f.level {
+		*f.reporting = ""
+	}
+	return nil
+}
+
+func (f reportingFlag) IsBoolFlag() bool {
+	return true
 }
 
 func initConfig() *config {
-	_ = flag.Bool("b", true, "binary mode (no-op)")
+	var c config
+	algorithm := programAlgorithm(os.Args[0])
+	program = algorithm.Name + "sum"
+	name := flag.String("a", "", "checksum algorithm: md5, sha1, sha256 or sha512 (default by program name, e.g. sha1sum, otherwise sha256; in check mode detected by digest length)")
+	b1 := flag.Bool("b", false, "read in binary mode (marks lines with '*')")
+	b2 := flag.Bool("binary", false, "read in binary mode (marks lines with '*')")
 	c1 := flag.Bool("c", false, "verify existing checksums")
 	c2 := flag.Bool("check", false, "verify existing checksums")
-	_ = flag.Bool("t", false, "text mode (no-op)")
-	quiet := flag.Bool("quiet", false, "Silence reporting output.")
+	t1 := flag.Bool("t", false, "read in text mode (default)")
+	t2 := flag.Bool("text", false, "read in text mode (default)")
+	z1 := flag.Bool("z", false, "end each output line with NUL, not newline, and disable file name escaping")
+	z2 := flag.Bool("zero", false, "end each output line with NUL, not newline, and disable file name escaping")
+	flag.BoolVar(&c.ignoreMissing, "ignore-missing", false, "don't fail or report status for missing files")
+	flag.Var(reportingFlag{&c.reporting, "quiet"}, "quiet", "don't print OK for each successfully verified file")
+	flag.Var(reportingFlag{&c.reporting, "status"}, "status", "don't output anything, status code shows success")
+	flag.BoolVar(&c.strict, "strict", false, "exit non-zero for improperly formatted checksum lines")
+	flag.Var(reportingFlag{&c.reporting, "warn"}, "w", "warn about improperly formatted checksum lines")
+	flag.Var(reportingFlag{&c.reporting, "warn"}, "warn", "warn about improperly formatted checksum lines")
 	flag.Parse()
 
-	var c config
 	c.checkMode = *c1 || *c2
+	c.binary = *b1 || *b2
+	c.text = *t1 || *t2
+	c.zero = *z1 || *z2
 	if flag.NArg() == 0 {
 		c.sources = []string{"-"}
 	} else {
 		c.sources = append(c.sources, flag.Args()...)
 	}
-	c.quiet = *quiet
+	c.algorithm, c.detect = algorithm, true
+	if *name != "" {
+		var ok bool
+		c.algorithm, ok = checksum_.ByName(*name)
+		if !ok {
+			os.Stderr.WriteString(program + ": unsupported algorithm '" + *name + "'\n")
+			os.Exit(1)
+		}
+		c.detect = false
+	}
 	return &c
 }
 
+// programAlgorithm determines the algorithm by the name of the program, such that the program can
+// be installed as e.g. sha1sum. The default is sha256.
+func programAlgorithm(path string) checksum_.Algorithm {
+	name := strings.TrimSuffix(filepath.Base(path), ".exe")
+	if algorithm, ok := checksum_.ByName(strings.TrimSuffix(name, "sum")); ok && strings.HasSuffix(name, "sum") {
+		return algorithm
+	}
+	return checksum_.SHA256
+}
+
 func verifyConfig(config *config) error {
-	if !config.checkMode && config.quiet {
-		os.Stderr.WriteString("sha256sum: the --quiet option is meaningful only when verifying checksums\n")
-		return os.ErrInvalid
+	var message string
+	if config.checkMode && config.zero {
+		message = "the --zero option is not supported when verifying checksums"
+	} else if config.checkMode && (config.binary || config.text) {
+		message = "the --binary and --text options are meaningless when verifying checksums"
+	} else if !config.checkMode && config.ignoreMissing {
+		message = "the --ignore-missing option is meaningful only when verifying checksums"
+	} else if !config.checkMode && config.reporting != "" {
+		message = "the --" + config.reporting + " option is meaningful only when verifying checksums"
+	} else if !config.checkMode && config.strict {
+		message = "the --strict option is meaningful only when verifying checksums"
+	} else {
+		return nil
 	}
-	return nil
+	os.Stderr.WriteString(program + ": " + message + "\n")
+	return os.ErrInvalid
 }
 
-var checksumLineFormat = regexp.MustCompile(`^([0-9a-f]{64}) \*(.+)$`)
+// checksumLineFormat matches checksum lines of any supported algorithm. The algorithm is determined
+// by the length of the digest. The digest is followed by a space and, usually, the mode: a space for
+// text mode or an asterisk for binary mode.
+var checksumLineFormat = regexp.MustCompile(`^([0-9a-fA-F]{32}|[0-9a-fA-F]{40}|[0-9a-fA-F]{64}|[0-9a-fA-F]{128})[ \t](.+)$`)
 
-func verifySource(c *config, source string) (uint, error) {
-	var reader *bufio.Reader
+// lineLayout records whether checksum lines include the mode. As in coreutils,
+// layouts cannot be mixed, to prevent confusion over file names with a leading space or asterisk.
+type lineLayout int
+
+const (
+	layoutUnknown lineLayout = iota
+	layoutMode
+	layoutNoMode
+)
+
+// verifySource verifies the checksums listed in source, reporting in the manner of coreutils.
+// verifySource returns whether all listed files were successfully verified.
+func verifySource(c *config, source string) bool {
+	name := source
+	var in io.Reader
 	if source == "-" {
-		reader = bufio.NewReader(os.Stdin)
+		name, in = "standard input", os.Stdin
 	} else {
 		f, err := os.Open(source)
 		if err != nil {
-			return 0, os.ErrNotExist
+			writeError(source, err)
+			return false
 		}
 		defer io_.CloseLogged(f, "Failed to close source: %+v")
-		stat, err := f.Stat()
-		assert.Success(err, "Failed to acquire source metadata: %v")
-		if stat.IsDir() {
-			return 0, os.ErrInvalid
-		}
-		reader = bufio.NewReader(f)
+		in = f
 	}
-	found := uint(0)
-	failures := uint(0)
-	for {
+	reader := bufio.NewReader(in)
+	var found, improper, unreadable, failures, matched uint
+	for number := 1; ; number++ {
 		line, err := reader.ReadString('\n')
-		if err == io.EOF {
+		if line == "" && err == io.EOF {
 			break
 		}
-		assert.Success(err, "Unexpected interruption while reading content: %v")
-		matches := checksumLineFormat.FindStringSubmatch(strings.TrimRight(line, "\n\r"))
-		if matches == nil {
+		if errors.Is(err, syscall.EISDIR) {
+			// report a directory as such, rather than as a generic read error
+			writeError(name, err)
+			return false
+		}
+		if err != nil && err != io.EOF {
+			os.Stderr.WriteString(program + ": " + quote(name) + ": read error\n")
+			return false
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		algorithm, expected, fileName, ok := parseChecksumLine(c, line)
+		if !ok {
+			improper++
+			if c.reporting == "warn" {
+				os.Stderr.WriteString(fmt.Sprintf("%s: %s: %d: improperly formatted %s checksum line\n",
+					program, quote(name), number, strings.ToUpper(c.algorithm.Name)))
+			}
 			continue
 		}
 		found++
-		fileName := strings.TrimSpace(matches[2])
-		var actual []byte
-		if fileName == "-" {
-			actual, err = checksum(os.Stdin)
-		} else {
-			f, err := os.Open(fileName)
-			if err != nil {
-				return 0, os.ErrNotExist
+		actual, err := checksumFile(algorithm, fileName)
+		if err != nil {
+			if c.ignoreMissing && errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			unreadable++
+			writeError(fileName, err)
+			if c.reporting != "status" {
+				writeResult(os.Stdout, fileName, "FAILED open or read")
 			}
-			actual, err = checksum(f)
-			assert.Success(err, "sha256sum: "+fileName+": failed to read all content")
-			f.Close()
+			continue
 		}
-		if fmt.Sprintf("%064x", actual) != matches[1] {
+		if hex.EncodeToString(actual) != expected {
 			failures++
-			writeResult(c.quiet, fileName, false)
+			if c.reporting != "status" {
+				writeResult(os.Stdout, fileName, "FAILED")
+			}
 			continue
 		}
-		writeResult(c.quiet, fileName, true)
+		matched++
+		if c.reporting != "status" && c.reporting != "quiet" {
+			writeResult(os.Stdout, fileName, "OK")
+		}
 	}
 	if found == 0 {
-		return 0, io.ErrNoProgress
+		os.Stderr.WriteString(program + ": " + quote(name) + ": no properly formatted checksum lines found\n")
+		return false
 	}
-	return failures, nil
-}
-
-func writeResult(quiet bool, source string, success bool) {
-	if success && !quiet {
-		os.Stdout.WriteString(source + ": OK\n")
-	} else if !success {
-		os.Stdout.WriteString(source + ": FAILED\n")
+	if c.reporting != "status" {
+		writeWarning(improper, "line is improperly formatted", "lines are improperly formatted")
+		writeWarning(unreadable, "listed file could not be read", "listed files could not be read")
+		writeWarning(failures, "computed checksum did NOT match", "computed checksums did NOT match")
+	}
+	if c.ignoreMissing && matched == 0 {
+		os.Stderr.WriteString(program + ": " + quote(name) + ": no file was verified\n")
+		return false
 	}
+	return unreadable == 0 && failures == 0 && (!c.strict || improper == 0)
 }
 
-func checksumSource(out io.Writer, source string) ([]byte, error) {
-	var in io.Reader
-	if source == "-" {
-		in = os.Stdin
-	} else {
-		var err error
-		var f *os.File
-		if f, err = os.Open(source); err != nil {
-			return nil, os.ErrNotExist
+// parseChecksumLine parses a checksum line into the algorithm, the (lower-case) expected digest
+// and the file name. Leading whitespace is ignored. A leading backslash indicates that the file name
+// is escaped.
+func parseChecksumLine(c *config, line string) (checksum_.Algorithm, string, string, bool) {
+	line = strings.TrimLeft(line, " \t")
+	escaped := strings.HasPrefix(line, "\\")
+	if escaped {
+		line = line[1:]
+	}
+	matches := checksumLineFormat.FindStringSubmatch(line)
+	if matches == nil {
+		return checksum_.Algorithm{}, "", "", false
+	}
+	algorithm, _ := checksum_.BySize(len(matches[1]) / 2)
+	if !c.detect && algorithm.Name != c.algorithm.Name {
+		return checksum_.Algorithm{}, "", "", false
+	}
+	fileName := matches[2]
+	if len(fileName) == 1 || (fileName[0] != ' ' && fileName[0] != '*') {
+		if c.layout == layoutMode {
+			return checksum_.Algorithm{}, "", "", false
 		}
-		defer io_.CloseLogged(f, "Failed to close source: %+v")
-		stat, err := f.Stat()
-		assert.Success(err, "Failed to acquire file metadata: %v")
-		if stat.IsDir() {
-			return nil, os.ErrInvalid
+		c.layout = layoutNoMode
+	} else if c.layout != layoutNoMode {
+		c.layout = layoutMode
+		fileName = fileName[1:]
+	}
+	if fileName == "" {
+		return checksum_.Algorithm{}, "", "", false
+	}
+	if escaped {
+		var ok bool
+		if fileName, ok = unescape(fileName); !ok {
+			return checksum_.Algorithm{}, "", "", false
 		}
-		in = f
 	}
-	return checksum(in)
+	return algorithm, strings.ToLower(matches[1]), fileName, true
 }
 
-func checksum(in io.Reader) ([]byte, error) {
-	checksum := sha256.New()
-	if _, err := io.Copy(checksum, in); err != nil {
+// writeResult writes the result of verifying a file. As in coreutils, file names are escaped in
+// the same way as in checksum lines.
+func writeResult(out io.Writer, name string, result string) {
+	if needsEscape(name) {
+		name = "\\" + escape(name)
+	}
+	_, err := out.Write([]byte(name + ": " + result + "\n"))
+	assert.Success(err, "Failed to write result to stdout: %v")
+}
+
+// writeWarning writes a warning with a count, if the count is non-zero.
+func writeWarning(count uint, singular, plural string) {
+	if count == 1 {
+		os.Stderr.WriteString(program + ": WARNING: 1 " + singular + "\n")
+	} else if count > 1 {
+		os.Stderr.WriteString(fmt.Sprintf("%s: WARNING: %d %s\n", program, count, plural))
+	}
+}
+
+// writeError writes an error for a file in the manner of coreutils, e.g. "No such file or
+// directory".
+func writeError(name string, err error) {
+	var pathErr *fs.PathError
+	if errors.As(err, &pathErr) {
+		err = pathErr.Err
+	}
+	message := err.Error()
+	if message != "" {
+		message = strings.ToUpper(message[:1]) + message[1:]
+	}
+	os.Stderr.WriteString(program + ": " + quote(name) + ": " + message + "\n")
+}
+
+// checksumFile computes the checksum of a file, with "-" indicating stdin.
+func checksumFile(algorithm checksum_.Algorithm, name string) ([]byte, error) {
+	if name == "-" {
+		return checksum_.Sum(algorithm, os.Stdin)
+	}
+	f, err := os.Open(name)
+	if err != nil {
 		return nil, err
 	}
-	return checksum.Sum(nil), nil
+	defer io_.CloseLogged(f, "Failed to close file: %+v")
+	return checksum_.Sum(algorithm, f)
 }
 
-func writeChecksum(out io.Writer, checksum []byte, name string) {
-	_, err := out.Write([]byte(fmt.Sprintf("%064x *%s\n", checksum, name)))
+// writeChecksum writes a checksum line. File names are escaped, unless lines are NUL-terminated.
+func writeChecksum(out io.Writer, c *config, checksum []byte, name string) {
+	prefix, mode, end := "", " ", "\n"
+	if c.binary {
+		mode = "*"
+	}
+	if c.zero {
+		end = "\x00"
+	} else if needsEscape(name) {
+		prefix, name = "\\", escape(name)
+	}
+	_, err := out.Write([]byte(fmt.Sprintf("%s%x %s%s%s", prefix, checksum, mode, name, end)))
 	assert.Success(err, "Failed to write checksum line to stdout: %v")
 }
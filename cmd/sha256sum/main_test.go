@@ -0,0 +1,22 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteResult(t *testing.T) {
+	cases := map[string]string{
+		"plain": "plain: OK\n",
+		"x\\y":  "\\x\\\\y: OK\n",
+		"l\nm":  "\\l\\nm: OK\n",
+		"c\rd":  "\\c\\rd: OK\n",
+	}
+	for name, expected := range cases {
+		var out bytes.Buffer
+		writeResult(&out, name, "OK")
+		if out.String() != expected {
+			t.Errorf("Expected result for %q to be %q, got %q", name, expected, out.String())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cobratbq/goutils/assert"
+	io_ "github.com/cobratbq/goutils/std/io"
+	"github.com/cobratbq/keysmap-tools/internal/keyring"
+	"github.com/cobratbq/keysmap-tools/internal/signature"
+)
+
+// verify-signature verifies the detached signature of an artifact with the keys from a keyring. A
+// line is written for every signature in the signature file:
+//
+//	good <KEYID> 0x<FINGERPRINT>
+//	bad <KEYID> 0x<FINGERPRINT>
+//	unknown key <KEYID>
+//
+// The fingerprint is that of the primary key of the (candidate) signing key. The exit code is 0 if
+// all signatures are good, 1 if any signature is bad or the signature file is unusable, and 2 if a
+// signature's key is not in the keyring.
+func main() {
+	keys := flag.String("k", "", "Keyring file or directory of key files.")
+	flag.Usage = func() {
+		os.Stderr.WriteString("Usage: " + os.Args[0] + " -k <keyring> <artifact> <signature>\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	assert.Require(*keys != "", "A keyring file or directory is required (-k).")
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	entities, err := keyring.Load(*keys)
+	assert.Success(err, "Failed to load keys: %+v")
+	artifact, err := os.Open(flag.Arg(0))
+	assert.Success(err, "Failed to open artifact: %+v")
+	defer io_.CloseLogged(artifact, "Failed to close artifact: %+v")
+	content, err := os.ReadFile(flag.Arg(1))
+	assert.Success(err, "Failed to read signature: %+v")
+
+	verifications, err := signature.Verify(artifact, content, entities)
+	if err == io.EOF {
+		os.Stderr.WriteString("No signature in " + flag.Arg(1) + "\n")
+		os.Exit(1)
+	}
+	if err != nil {
+		os.Stderr.WriteString("Failed to read signature " + flag.Arg(1) + ": " + err.Error() + "\n")
+		os.Exit(1)
+	}
+	bad, unknown := false, false
+	for _, v := range verifications {
+		switch v.Verdict {
+		case signature.Good:
+			os.Stdout.WriteString(fmt.Sprintf("good %016X 0x%040X\n", v.Issuer.KeyID, v.Signer.PrimaryKey.Fingerprint))
+		case signature.Bad:
+			bad = true
+			os.Stdout.WriteString(fmt.Sprintf("bad %016X 0x%040X\n", v.Issuer.KeyID, v.Signer.PrimaryKey.Fingerprint))
+			if v.Err != nil {
+				os.Stderr.WriteString(fmt.Sprintf("Bad signature by %016X: %v\n", v.Issuer.KeyID, v.Err))
+			}
+		case signature.UnknownKey:
+			unknown = true
+			os.Stdout.WriteString(fmt.Sprintf("unknown key %016X\n", v.Issuer.KeyID))
+		}
+	}
+	if bad {
+		os.Exit(1)
+	}
+	if unknown {
+		os.Exit(2)
+	}
+}
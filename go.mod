@@ -3,12 +3,12 @@ module github.com/cobratbq/keysmap-tools
 go 1.19
 
 require (
-	github.com/ProtonMail/go-crypto v0.0.0-20230717121422-5aa5874ade95
+	github.com/ProtonMail/go-crypto v1.1.6
 	github.com/cobratbq/goutils v0.0.0-20240617174750-7c91075dc8a1
-	golang.org/x/crypto v0.11.0
+	golang.org/x/crypto v0.17.0
 )
 
 require (
-	github.com/cloudflare/circl v1.3.3 // indirect
-	golang.org/x/sys v0.10.0 // indirect
+	github.com/cloudflare/circl v1.3.7 // indirect
+	golang.org/x/sys v0.16.0 // indirect
 )
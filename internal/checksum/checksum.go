@@ -0,0 +1,63 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+// Package checksum computes checksums with the hash algorithms that Maven repositories publish
+// checksums for.
+package checksum
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"io"
+)
+
+// Algorithm is a checksum algorithm. Name is also the extension of checksum files in a Maven
+// repository, e.g. `.sha1`.
+type Algorithm struct {
+	Name string
+	New  func() hash.Hash
+	// Size is the size of the digest in bytes.
+	Size int
+}
+
+var (
+	MD5    = Algorithm{Name: "md5", New: md5.New, Size: md5.Size}
+	SHA1   = Algorithm{Name: "sha1", New: sha1.New, Size: sha1.Size}
+	SHA256 = Algorithm{Name: "sha256", New: sha256.New, Size: sha256.Size}
+	SHA512 = Algorithm{Name: "sha512", New: sha512.New, Size: sha512.Size}
+)
+
+// Algorithms lists the supported algorithms, strongest first.
+var Algorithms = []Algorithm{SHA512, SHA256, SHA1, MD5}
+
+// Sum computes the checksum of all content read from `in`.
+func Sum(algorithm Algorithm, in io.Reader) ([]byte, error) {
+	h := algorithm.New()
+	if _, err := io.Copy(h, in); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// ByName looks up an algorithm by its name, e.g. `sha1`.
+func ByName(name string) (Algorithm, bool) {
+	for _, algorithm := range Algorithms {
+		if algorithm.Name == name {
+			return algorithm, true
+		}
+	}
+	return Algorithm{}, false
+}
+
+// BySize looks up an algorithm by the size of its digest in bytes. The supported algorithms all
+// have distinct digest sizes.
+func BySize(size int) (Algorithm, bool) {
+	for _, algorithm := range Algorithms {
+		if algorithm.Size == size {
+			return algorithm, true
+		}
+	}
+	return Algorithm{}, false
+}
@@ -0,0 +1,34 @@
+package checksum
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestSum(t *testing.T) {
+	expected := map[string]string{
+		"md5":    "5d41402abc4b2a76b9719d911017c592",
+		"sha1":   "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d",
+		"sha256": "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824",
+	}
+	for name, digest := range expected {
+		algorithm, ok := ByName(name)
+		if !ok {
+			t.Fatalf("Expected algorithm %s to be supported.", name)
+		}
+		sum, err := Sum(algorithm, strings.NewReader("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(sum) != digest {
+			t.Errorf("Unexpected %s checksum: %x", name, sum)
+		}
+		if bySize, ok := BySize(len(digest) / 2); !ok || bySize.Name != name {
+			t.Errorf("Expected %s for digest size %d, got: %s", name, len(digest)/2, bySize.Name)
+		}
+	}
+	if _, ok := ByName("sha3"); ok {
+		t.Error("Expected unsupported algorithm to be absent.")
+	}
+}
@@ -0,0 +1,195 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+// Package download downloads the signatures of artifact versions from Maven repositories. The
+// signature of the main artifact of a version is stored as `<groupID>:<artifactID>:<version>.asc`,
+// all other files are stored under their original names, in a directory named after the version.
+package download
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cobratbq/goutils/assert"
+	os_ "github.com/cobratbq/goutils/std/os"
+	"github.com/cobratbq/keysmap-tools/internal/repository"
+	"github.com/cobratbq/keysmap-tools/internal/signature"
+)
+
+// Classifier identifies a classified artifact of a version by its classifier and extension.
+type Classifier struct {
+	Name      string
+	Extension string
+}
+
+// JarClassifiers creates classifiers for jar artifacts. Classified artifacts, such as sources and
+// javadoc, are (nearly) always jar files.
+func JarClassifiers(names []string) []Classifier {
+	classifiers := make([]Classifier, 0, len(names))
+	for _, name := range names {
+		classifiers = append(classifiers, Classifier{Name: name, Extension: "jar"})
+	}
+	return classifiers
+}
+
+// Extension returns the extension of an artifact of the given packaging type, as packaging and
+// extension differ for some types, e.g. `maven-plugin`.
+func Extension(packaging string) string {
+	return packagingExtension(packaging)
+}
+
+// Version downloads the signatures of the main artifact and POM of a version, and of the classified
+// artifacts. If extension is empty, the extension of the main artifact is determined by the
+// packaging in the POM. Version returns the report of its progress and a record for every failure.
+// An empty signature file indicates that the signature was not published.
+func Version(client *repository.Client, destination, extension string, classifiers []Classifier, groupID, artifactID, version string) (string, []string) {
+	var report strings.Builder
+	var failures []string
+	versionDir := path.Join(destination, Coordinate(groupID, artifactID, version))
+	versionPath := path.Join(repository.GroupPath(groupID), artifactID, version)
+	prefix := artifactID + "-" + version
+	if extension == "" {
+		packaging, err := determinePackaging(client, versionDir, versionPath, prefix, &report)
+		if err != nil {
+			report.WriteString("  failed: " + err.Error() + "\n")
+			return report.String(), []string{versionDir + ": " + err.Error()}
+		}
+		extension = packagingExtension(packaging)
+	} else if err := os.MkdirAll(versionDir, 0755); err != nil {
+		report.WriteString("  failed: " + err.Error() + "\n")
+		return report.String(), []string{versionDir + ": " + err.Error()}
+	}
+	files := []signedFile{{
+		destinationPath: SignaturePath(destination, groupID, artifactID, version),
+		relpath:         path.Join(versionPath, prefix+"."+extension+".asc"),
+		checkArtifact:   true,
+	}}
+	if extension != "pom" {
+		files = append(files, signedFile{
+			destinationPath: path.Join(versionDir, prefix+".pom.asc"),
+			relpath:         path.Join(versionPath, prefix+".pom.asc"),
+		})
+	}
+	for _, classifier := range classifiers {
+		name := prefix + "-" + classifier.Name + "." + classifier.Extension + ".asc"
+		files = append(files, signedFile{
+			destinationPath: path.Join(versionDir, name),
+			relpath:         path.Join(versionPath, name),
+		})
+	}
+	for _, f := range files {
+		if err := downloadSignature(client, f, &report); err != nil {
+			failures = append(failures, f.destinationPath+": "+err.Error())
+		}
+	}
+	reportSigners(files, &report)
+	return report.String(), failures
+}
+
+// SignaturePath is the path of the signature of the main artifact of a version.
+func SignaturePath(destination, groupID, artifactID, version string) string {
+	return path.Join(destination, Coordinate(groupID, artifactID, version)+".asc")
+}
+
+// Coordinate formats the coordinate `<groupID>:<artifactID>:<version>`.
+func Coordinate(groupID, artifactID, version string) string {
+	return strings.Join([]string{groupID, ":", artifactID, ":", version}, "")
+}
+
+type signedFile struct {
+	destinationPath string
+	relpath         string
+	// checkArtifact indicates that a missing signature only counts as not published if the signed
+	// artifact exists, as the artifact's extension is derived from its packaging.
+	checkArtifact bool
+}
+
+// downloadSignature downloads a single signature, writing its progress to report. An error is
+// returned if the download failed, even after retrying, or if the downloaded content is not a
+// signature. The outcome of the download is recorded next to the signature.
+func downloadSignature(client *repository.Client, f signedFile, report *strings.Builder) error {
+	if isFinal(f.destinationPath, f.relpath) {
+		// As artifact signatures are extremely unlikely to change, there
+		// is no sense in even thinking of downloading them again.
+		report.WriteString("Skipping " + f.destinationPath + "\n")
+		return nil
+	}
+	report.WriteString("Downloading " + f.relpath + " ...\n")
+	outcome, err := client.Download(f.destinationPath, f.relpath)
+	if err == repository.ErrNotFound && f.checkArtifact {
+		if exists, existsErr := client.Exists(strings.TrimSuffix(f.relpath, ".asc")); existsErr != nil {
+			err = existsErr
+		} else if !exists {
+			err = ErrArtifactNotFound
+		}
+		if err != repository.ErrNotFound {
+			outcome.Error = err.Error()
+		}
+	}
+	if err == repository.ErrNotFound {
+		// no need to panic if document is simply not found (404)
+		assert.Success(os_.CreateEmptyFile(f.destinationPath),
+			"Failed to create empty file "+f.destinationPath+": %+v")
+		report.WriteString("  not found: " + f.relpath + "\n")
+		err = nil
+	} else if err == nil {
+		if err = validateSignature(f.destinationPath); err != nil {
+			outcome.Error = err.Error()
+		}
+	}
+	if err != nil {
+		// Remove any (stale) signature file, such that it cannot be mistaken for a missing
+		// signature and a next run will attempt to download again.
+		if rmErr := os.Remove(f.destinationPath); rmErr != nil && !os.IsNotExist(rmErr) {
+			report.WriteString("  failed to remove " + f.destinationPath + ": " + rmErr.Error() + "\n")
+		}
+	}
+	assert.Success(repository.WriteOutcome(f.destinationPath, outcome),
+		"Failed to record download outcome for "+f.destinationPath+": %+v")
+	if err != nil {
+		report.WriteString("  failed: " + err.Error() + "\n")
+		return err
+	}
+	if outcome.StatusCode == http.StatusOK {
+		report.WriteString("  from " + outcome.URL + "\n")
+	}
+	if outcome.Checksum != "" {
+		report.WriteString("  verified " + outcome.Checksum + " checksum\n")
+	}
+	return nil
+}
+
+// reportSigners reports if the files of a version are signed by different (sets of) keys.
+func reportSigners(files []signedFile, report *strings.Builder) {
+	var names, signers []string
+	distinct := make(map[string]struct{})
+	for _, f := range files {
+		content, err := os.ReadFile(f.destinationPath)
+		if err != nil || len(content) == 0 {
+			continue
+		}
+		issuers, err := signature.ReadIssuers(content)
+		if err != nil {
+			report.WriteString("  unreadable signature " + f.destinationPath + ": " + err.Error() + "\n")
+			continue
+		}
+		formatted := make([]string, 0, len(issuers))
+		for _, issuer := range issuers {
+			formatted = append(formatted, fmt.Sprintf("%016X", issuer.KeyID))
+		}
+		sort.Strings(formatted)
+		names = append(names, path.Base(f.destinationPath))
+		signers = append(signers, strings.Join(formatted, ", "))
+		distinct[signers[len(signers)-1]] = struct{}{}
+	}
+	if len(distinct) <= 1 {
+		return
+	}
+	report.WriteString("  WARNING: files signed by different keys:\n")
+	for i := range names {
+		report.WriteString("    " + names[i] + ": " + signers[i] + "\n")
+	}
+}
@@ -0,0 +1,66 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package download
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	io_ "github.com/cobratbq/goutils/std/io"
+	"github.com/cobratbq/keysmap-tools/internal/repository"
+)
+
+// ErrNotSignature indicates that downloaded content is not an armored PGP signature.
+var ErrNotSignature = errors.New("content is not an armored PGP signature")
+
+// ErrArtifactNotFound indicates that neither the main artifact nor its signature exists, which
+// suggests that the extension derived from the packaging is wrong.
+var ErrArtifactNotFound = errors.New("artifact not found, its extension may not match the packaging")
+
+// isFinal determines whether a previously downloaded signature is final, i.e. should not be
+// downloaded again. This is the case if the signature at `relpath` was not published (404), or if it
+// was downloaded successfully and contains an armored PGP signature. Any other file, including files
+// from before outcomes were recorded, may be the result of a failed download.
+func isFinal(destinationPath, relpath string) bool {
+	outcome, err := repository.ReadOutcome(destinationPath)
+	if err != nil || !strings.HasSuffix(outcome.URL, "/"+relpath) {
+		return false
+	}
+	stat, err := os.Stat(destinationPath)
+	if err != nil {
+		return false
+	}
+	switch outcome.StatusCode {
+	case http.StatusNotFound:
+		return stat.Size() == 0
+	case http.StatusOK:
+		return stat.Size() == outcome.Size && validateSignature(destinationPath) == nil
+	default:
+		return false
+	}
+}
+
+// validateSignature checks that the file contains an armored PGP signature, including a correct
+// armor checksum if present.
+func validateSignature(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer io_.CloseLogged(f, "Failed to close signature file: %+v")
+	block, err := armor.Decode(f)
+	if err != nil {
+		return ErrNotSignature
+	}
+	if block.Type != "PGP SIGNATURE" {
+		return ErrNotSignature
+	}
+	if _, err = io.Copy(io.Discard, block.Body); err != nil {
+		return ErrNotSignature
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package download
+
+import (
+	"encoding/xml"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/cobratbq/keysmap-tools/internal/repository"
+)
+
+// packagingExtensions maps packaging types to the extension of the main artifact, for packaging
+// types for which these differ.
+var packagingExtensions = map[string]string{
+	"bundle":              "jar",
+	"maven-plugin":        "jar",
+	"maven-archetype":     "jar",
+	"eclipse-plugin":      "jar",
+	"ejb":                 "jar",
+	"ejb-client":          "jar",
+	"glassfish-jar":       "jar",
+	"hk2-jar":             "jar",
+	"java-source":         "jar",
+	"javadoc":             "jar",
+	"orbit":               "jar",
+	"takari-jar":          "jar",
+	"takari-maven-plugin": "jar",
+	"test-jar":            "jar",
+}
+
+func packagingExtension(packaging string) string {
+	if extension, ok := packagingExtensions[packaging]; ok {
+		return extension
+	}
+	return packaging
+}
+
+type pom struct {
+	Packaging string `xml:"packaging"`
+}
+
+// determinePackaging determines the packaging of a version from its POM. The POM is stored in the
+// version directory, such that it need not be downloaded again. If the POM does not exist, packaging
+// `jar` is assumed.
+func determinePackaging(client *repository.Client, versionDir, versionPath, prefix string, report *strings.Builder) (string, error) {
+	pomPath := path.Join(versionDir, prefix+".pom")
+	if _, err := os.Stat(pomPath); err != nil {
+		if err := os.MkdirAll(versionDir, 0755); err != nil {
+			return "", err
+		}
+		_, err := client.Download(pomPath, path.Join(versionPath, prefix+".pom"))
+		if err == repository.ErrNotFound {
+			report.WriteString("  POM not found, assuming packaging 'jar': " + prefix + ".pom\n")
+			return "jar", nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	data, err := os.ReadFile(pomPath)
+	if err != nil {
+		return "", err
+	}
+	var p pom
+	if err = xml.Unmarshal(data, &p); err != nil {
+		return "", err
+	}
+	if p.Packaging = strings.TrimSpace(p.Packaging); p.Packaging == "" {
+		return "jar", nil
+	}
+	return p.Packaging, nil
+}
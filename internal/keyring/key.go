@@ -0,0 +1,149 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package keyring
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// State is the validity state of a key.
+type State uint
+
+const (
+	Valid State = iota
+	Expired
+	Revoked
+)
+
+// Status describes the validity of a key or subkey at a moment in time. Since is the expiration or
+// revocation date, Reason and ReasonText are only set for revoked keys.
+type Status struct {
+	State      State
+	Since      time.Time
+	Reason     packet.ReasonForRevocation
+	ReasonText string
+}
+
+// String formats the status as `valid`, `expired:<date>` or `revoked:<date>:<reason>`.
+func (s Status) String() string {
+	switch s.State {
+	case Valid:
+		return "valid"
+	case Expired:
+		return "expired:" + s.Since.UTC().Format("2006-01-02")
+	case Revoked:
+		return "revoked:" + s.Since.UTC().Format("2006-01-02") + ":" + reasonName(s.Reason)
+	default:
+		panic("BUG: unknown key state " + strconv.Itoa(int(s.State)))
+	}
+}
+
+func reasonName(reason packet.ReasonForRevocation) string {
+	switch reason {
+	case packet.NoReason:
+		return "no-reason"
+	case packet.KeySuperseded:
+		return "key-superseded"
+	case packet.KeyCompromised:
+		return "key-compromised"
+	case packet.KeyRetired:
+		return "key-retired"
+	default:
+		return "reason-" + strconv.Itoa(int(reason))
+	}
+}
+
+// PrimaryKeyStatus determines the status of the primary key at time `now`. Revocation takes
+// precedence over expiration. Revocation signatures are verified while reading the key.
+func PrimaryKeyStatus(key *openpgp.Entity, now time.Time) Status {
+	if status, revoked := revocationStatus(key.Revocations, now); revoked {
+		return status
+	}
+	selfSignature, _ := key.PrimarySelfSignature()
+	if selfSignature != nil && key.PrimaryKey.KeyExpired(selfSignature, now) {
+		return Status{State: Expired, Since: expiration(key.PrimaryKey, selfSignature)}
+	}
+	return Status{State: Valid}
+}
+
+// SubkeyStatus determines the status of a subkey at time `now`. A subkey is not valid if its primary
+// key is not valid, in which case the status of the primary key is returned.
+func SubkeyStatus(key *openpgp.Entity, subkey *openpgp.Subkey, now time.Time) Status {
+	if status := PrimaryKeyStatus(key, now); status.State != Valid {
+		return status
+	}
+	if status, revoked := revocationStatus(subkey.Revocations, now); revoked {
+		return status
+	}
+	if subkey.PublicKey.KeyExpired(subkey.Sig, now) {
+		return Status{State: Expired, Since: expiration(subkey.PublicKey, subkey.Sig)}
+	}
+	if subkey.Sig.SigExpired(now) {
+		since := subkey.Sig.CreationTime
+		if subkey.Sig.SigLifetimeSecs != nil {
+			since = since.Add(time.Duration(*subkey.Sig.SigLifetimeSecs) * time.Second)
+		}
+		return Status{State: Expired, Since: since}
+	}
+	return Status{State: Valid}
+}
+
+// revocationStatus determines the status according to the revocation signatures, following the
+// same rules as ProtonMail/go-crypto: a compromised key is considered revoked even before the
+// revocation date.
+func revocationStatus(revocations []*packet.Signature, now time.Time) (Status, bool) {
+	for _, revocation := range revocations {
+		compromised := revocation.RevocationReason != nil && *revocation.RevocationReason == packet.KeyCompromised
+		if !compromised && revocation.SigExpired(now) {
+			continue
+		}
+		status := Status{State: Revoked, Since: revocation.CreationTime, Reason: packet.NoReason,
+			ReasonText: revocation.RevocationReasonText}
+		if revocation.RevocationReason != nil {
+			status.Reason = *revocation.RevocationReason
+		}
+		return status, true
+	}
+	return Status{}, false
+}
+
+func expiration(key *packet.PublicKey, selfSignature *packet.Signature) time.Time {
+	if selfSignature.KeyLifetimeSecs == nil || *selfSignature.KeyLifetimeSecs == 0 {
+		// key creation time in the future
+		return key.CreationTime
+	}
+	return key.CreationTime.Add(time.Duration(*selfSignature.KeyLifetimeSecs) * time.Second)
+}
+
+// SigningCapableSubkeys returns the subkeys of key whose binding signature marks them as
+// signing-capable, regardless of their status. Binding signatures, including the cross-signature that
+// a signing subkey requires, are verified while reading the key. ProtonMail/go-crypto rejects the
+// entire key if any binding signature is invalid, so such keys never reach this function. See
+// ReadKeysSkipped for reporting rejected keys.
+func SigningCapableSubkeys(key *openpgp.Entity) []*openpgp.Subkey {
+	var subkeys []*openpgp.Subkey
+	for i := range key.Subkeys {
+		subkey := &key.Subkeys[i]
+		if subkey.Sig == nil || !subkey.Sig.FlagsValid || !subkey.Sig.FlagSign ||
+			!subkey.PublicKey.PubKeyAlgo.CanSign() {
+			continue
+		}
+		subkeys = append(subkeys, subkey)
+	}
+	return subkeys
+}
+
+// SigningSubkeys returns the signing-capable subkeys of key that are valid at time `now`.
+func SigningSubkeys(key *openpgp.Entity, now time.Time) []*openpgp.Subkey {
+	var subkeys []*openpgp.Subkey
+	for _, subkey := range SigningCapableSubkeys(key) {
+		if SubkeyStatus(key, subkey, now).State == Valid {
+			subkeys = append(subkeys, subkey)
+		}
+	}
+	return subkeys
+}
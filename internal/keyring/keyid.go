@@ -0,0 +1,70 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package keyring
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/cobratbq/goutils/assert"
+)
+
+// keyidPattern matches the key-ids as produced by extract-keyid, optionally with `0x` prefix or with
+// the `keyid:` marker.
+var keyidPattern = regexp.MustCompile(`^(?:0x|keyid:)?([0-9a-fA-F]{16})$`)
+
+// ReadKeyIDs reads key-ids, one per line. Empty lines and comments are ignored. Lines that are not
+// a key-id are reported and skipped.
+func ReadKeyIDs(reader *bufio.Reader) []uint64 {
+	var keyids []uint64
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF && line == "" {
+			break
+		}
+		if err != io.EOF {
+			assert.Success(err, "Unexpected failure reading line: %v")
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		matches := keyidPattern.FindStringSubmatch(line)
+		if matches == nil {
+			os.Stderr.WriteString("WARNING: Line does not match format: " + line + "\n")
+			continue
+		}
+		keyid, err := strconv.ParseUint(matches[1], 16, 64)
+		assert.Success(err, "BUG: failed to parse matched key-id: %v")
+		keyids = append(keyids, keyid)
+	}
+	return keyids
+}
+
+// WriteResolution writes the key-id with the fingerprint of the primary key of its only candidate,
+// or with `noKey` if there is no candidate. A key-id collision cannot be resolved without the
+// signature itself, so for multiple candidates the collision is reported and `noKey` is written,
+// such that the output remains valid keysmap input.
+func WriteResolution(out io.Writer, keyid uint64, candidates []*openpgp.Entity) {
+	var err error
+	switch len(candidates) {
+	case 0:
+		_, err = fmt.Fprintf(out, "%016X = noKey\n", keyid)
+	case 1:
+		_, err = fmt.Fprintf(out, "%016X = 0x%040X\n", keyid, candidates[0].PrimaryKey.Fingerprint)
+	default:
+		os.Stderr.WriteString(fmt.Sprintf("WARNING: ambiguous key-id %016X:", keyid))
+		for _, candidate := range candidates {
+			os.Stderr.WriteString(fmt.Sprintf(" 0x%040X", candidate.PrimaryKey.Fingerprint))
+		}
+		os.Stderr.WriteString("\n")
+		_, err = fmt.Fprintf(out, "%016X = noKey\n", keyid)
+	}
+	assert.Success(err, "Failed to write key-id resolution: %v")
+}
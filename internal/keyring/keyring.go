@@ -0,0 +1,251 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+// Package keyring loads public keys from keyring files and directories of key files, and indexes
+// them by key-id.
+package keyring
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	pgperrors "github.com/ProtonMail/go-crypto/openpgp/errors"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	io_ "github.com/cobratbq/goutils/std/io"
+)
+
+// ErrNoKeys indicates that the input does not contain any (supported) keys.
+var ErrNoKeys = errors.New("no keys found")
+
+// keyFileExtensions are the extensions of files that are loaded from a directory.
+var keyFileExtensions = map[string]struct{}{".asc": {}, ".gpg": {}, ".pgp": {}, ".key": {}}
+
+// Load loads all keys from a keyring file, or from all key files in a directory. Key files are
+// recognized by extension: `.asc`, `.gpg`, `.pgp` or `.key`. Subdirectories are not loaded.
+func Load(path string) (openpgp.EntityList, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return loadFile(path)
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	var keys openpgp.EntityList
+	for _, entry := range entries {
+		if _, ok := keyFileExtensions[strings.ToLower(filepath.Ext(entry.Name()))]; !ok || entry.IsDir() {
+			continue
+		}
+		fileKeys, err := loadFile(filepath.Join(path, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, fileKeys...)
+	}
+	return keys, nil
+}
+
+func loadFile(path string) (openpgp.EntityList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer io_.CloseLogged(f, "Failed to close key file: %+v")
+	keys, err := ReadKeys(f)
+	if err != nil {
+		return nil, errors.New(path + ": " + err.Error())
+	}
+	return keys, nil
+}
+
+// ReadKeys reads all keys from `in`, which contains either binary OpenPGP packets or any number of
+// armored key blocks. Armored blocks other than key blocks are skipped. Keys that cannot be read are
+// skipped, see ReadKeysSkipped.
+func ReadKeys(in io.Reader) (openpgp.EntityList, error) {
+	keys, _, err := ReadKeysSkipped(in)
+	return keys, err
+}
+
+// SkippedKey is a key that was skipped while reading keys, because it is malformed or unsupported.
+// ProtonMail/go-crypto rejects a key as a whole if, e.g., any of its subkey binding signatures is
+// invalid. Fingerprint is nil if the primary key itself could not be read.
+type SkippedKey struct {
+	Fingerprint []byte
+	Err         error
+}
+
+// ReadKeysSkipped reads all keys like ReadKeys, and also returns the keys that were skipped. If only
+// skipped keys are found, ErrNoKeys is returned together with the skipped keys.
+func ReadKeysSkipped(in io.Reader) (openpgp.EntityList, []SkippedKey, error) {
+	// armor.Decode reuses a bufio.Reader if large enough, so no data is lost between blocks.
+	reader := bufio.NewReader(in)
+	first, err := reader.Peek(1)
+	if err == io.EOF {
+		return nil, nil, ErrNoKeys
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if first[0]&0x80 != 0 {
+		// binary OpenPGP packets always start with the packet tag, that has its high bit set.
+		return readKeyRing(reader)
+	}
+	var keys openpgp.EntityList
+	var skipped []SkippedKey
+	for {
+		block, err := armor.Decode(reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if block.Type != openpgp.PublicKeyType && block.Type != openpgp.PrivateKeyType {
+			io_.Discard(block.Body)
+			continue
+		}
+		blockKeys, blockSkipped, err := readKeyRing(block.Body)
+		skipped = append(skipped, blockSkipped...)
+		if err == ErrNoKeys && len(blockSkipped) > 0 {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, blockKeys...)
+	}
+	if len(keys) == 0 {
+		return nil, skipped, ErrNoKeys
+	}
+	return keys, skipped, nil
+}
+
+// readKeyRing reads keys like openpgp.ReadKeyRing, but records the keys that are skipped.
+func readKeyRing(in io.Reader) (openpgp.EntityList, []SkippedKey, error) {
+	packets := packet.NewReader(in)
+	var keys openpgp.EntityList
+	var skipped []SkippedKey
+	for {
+		p, err := packets.Next()
+		if err == io.EOF {
+			break
+		}
+		var fingerprint []byte
+		if err == nil {
+			fingerprint = primaryFingerprint(p)
+			packets.Unread(p)
+		}
+		var key *openpgp.Entity
+		if err == nil {
+			key, err = openpgp.ReadEntity(packets)
+		}
+		if err == nil {
+			keys = append(keys, key)
+			continue
+		}
+		_, unsupported := err.(pgperrors.UnsupportedError)
+		_, structural := err.(pgperrors.StructuralError)
+		if !unsupported && !structural {
+			return nil, nil, err
+		}
+		skipped = append(skipped, SkippedKey{Fingerprint: fingerprint, Err: err})
+		if err = readToNextKey(packets); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(keys) == 0 {
+		return nil, skipped, ErrNoKeys
+	}
+	return keys, skipped, nil
+}
+
+// primaryFingerprint returns the fingerprint if the packet is a primary (public or private) key.
+func primaryFingerprint(p packet.Packet) []byte {
+	switch key := p.(type) {
+	case *packet.PublicKey:
+		if !key.IsSubkey {
+			return key.Fingerprint
+		}
+	case *packet.PrivateKey:
+		if !key.IsSubkey {
+			return key.Fingerprint
+		}
+	}
+	return nil
+}
+
+// readToNextKey skips packets up to the primary key of the next key, which is left in the reader.
+func readToNextKey(packets *packet.Reader) error {
+	for {
+		p, err := packets.Next()
+		if _, ok := err.(pgperrors.UnsupportedError); ok {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if primaryFingerprint(p) != nil {
+			packets.Unread(p)
+			return nil
+		}
+	}
+}
+
+// WriteKeys writes the public keys as a single armored key block.
+func WriteKeys(out io.Writer, keys openpgp.EntityList) error {
+	w, err := armor.Encode(out, openpgp.PublicKeyType, nil)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err = key.Serialize(w); err != nil {
+			return err
+		}
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	_, err = out.Write([]byte{'\n'})
+	return err
+}
+
+// Index indexes keys by the key-ids of their primary key and subkeys.
+type Index map[uint64][]*openpgp.Entity
+
+// NewIndex creates an index for the keys. Duplicate keys, i.e. with same primary key fingerprint,
+// are indexed once.
+func NewIndex(keys openpgp.EntityList) Index {
+	index := make(Index)
+	for _, key := range keys {
+		index.add(key.PrimaryKey.KeyId, key)
+		for _, subkey := range key.Subkeys {
+			index.add(subkey.PublicKey.KeyId, key)
+		}
+	}
+	return index
+}
+
+func (idx Index) add(keyid uint64, key *openpgp.Entity) {
+	for _, existing := range idx[keyid] {
+		if bytes.Equal(existing.PrimaryKey.Fingerprint, key.PrimaryKey.Fingerprint) {
+			return
+		}
+	}
+	idx[keyid] = append(idx[keyid], key)
+}
+
+// Lookup returns the keys that have the key-id either for the primary key or one of its subkeys.
+// More than one key indicates a key-id collision.
+func (idx Index) Lookup(keyid uint64) []*openpgp.Entity {
+	return idx[keyid]
+}
@@ -0,0 +1,252 @@
+package keyring
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+func generateKey(t *testing.T) *openpgp.Entity {
+	entity, err := openpgp.NewEntity("Test", "", "test@example.org", &packet.Config{Algorithm: packet.PubKeyAlgoEd25519})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entity
+}
+
+func TestReadKeysArmoredBlocks(t *testing.T) {
+	var buffer bytes.Buffer
+	keys := []*openpgp.Entity{generateKey(t), generateKey(t)}
+	for _, key := range keys {
+		w, err := armor.Encode(&buffer, openpgp.PublicKeyType, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = key.Serialize(w); err != nil {
+			t.Fatal(err)
+		}
+		w.Close()
+		buffer.WriteString("\n")
+	}
+	read, err := ReadKeys(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(read))
+	}
+	for i := range keys {
+		if !bytes.Equal(read[i].PrimaryKey.Fingerprint, keys[i].PrimaryKey.Fingerprint) {
+			t.Errorf("Unexpected key %X, expected %X", read[i].PrimaryKey.Fingerprint, keys[i].PrimaryKey.Fingerprint)
+		}
+	}
+}
+
+func TestReadKeysBinaryAndIndex(t *testing.T) {
+	var buffer bytes.Buffer
+	key := generateKey(t)
+	if err := key.Serialize(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	// duplicate key must be indexed once
+	if err := key.Serialize(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadKeys(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := NewIndex(read)
+	if found := index.Lookup(key.PrimaryKey.KeyId); len(found) != 1 {
+		t.Errorf("Expected exactly one key for primary key-id, got %d", len(found))
+	}
+	if found := index.Lookup(key.Subkeys[0].PublicKey.KeyId); len(found) != 1 ||
+		!bytes.Equal(found[0].PrimaryKey.Fingerprint, key.PrimaryKey.Fingerprint) {
+		t.Errorf("Expected subkey key-id to resolve to primary key.")
+	}
+	if found := index.Lookup(0); len(found) != 0 {
+		t.Errorf("Expected no keys for unknown key-id.")
+	}
+}
+
+func TestSigningSubkeys(t *testing.T) {
+	key := generateKey(t)
+	if subkeys := SigningSubkeys(key, time.Now()); len(subkeys) != 0 {
+		t.Fatalf("Expected no signing subkeys for key with only encryption subkey, got %d", len(subkeys))
+	}
+	if err := key.AddSigningSubkey(&packet.Config{Algorithm: packet.PubKeyAlgoEd25519}); err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	if err := key.Serialize(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadKeys(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subkeys := SigningSubkeys(read[0], time.Now())
+	if len(subkeys) != 1 || subkeys[0].PublicKey.KeyId != key.Subkeys[1].PublicKey.KeyId {
+		t.Errorf("Expected exactly the added signing subkey.")
+	}
+}
+
+func TestKeyStatus(t *testing.T) {
+	key := generateKey(t)
+	if err := key.AddSigningSubkey(&packet.Config{Algorithm: packet.PubKeyAlgoEd25519}); err != nil {
+		t.Fatal(err)
+	}
+	if err := key.RevokeSubkey(&key.Subkeys[1], packet.KeySuperseded, "replaced", nil); err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	if err := key.Serialize(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadKeys(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if status := PrimaryKeyStatus(read[0], now); status.State != Valid {
+		t.Errorf("Expected valid primary key, got: %s", status)
+	}
+	subkeys := SigningCapableSubkeys(read[0])
+	if len(subkeys) != 1 {
+		t.Fatalf("Expected one signing-capable subkey, got %d", len(subkeys))
+	}
+	status := SubkeyStatus(read[0], subkeys[0], now)
+	if status.State != Revoked || status.Reason != packet.KeySuperseded || status.ReasonText != "replaced" {
+		t.Errorf("Expected subkey revoked as superseded, got: %s %q", status, status.ReasonText)
+	}
+	if len(SigningSubkeys(read[0], now)) != 0 {
+		t.Errorf("Expected revoked subkey to be excluded from valid signing subkeys.")
+	}
+	if err := key.RevokeKey(packet.KeyCompromised, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if status := PrimaryKeyStatus(key, now); status.State != Revoked || status.Reason != packet.KeyCompromised {
+		t.Errorf("Expected revoked primary key, got: %s", status)
+	}
+}
+
+func TestReadKeysSkipped(t *testing.T) {
+	var buffer bytes.Buffer
+	valid, invalid, other := generateKey(t), generateKey(t), generateKey(t)
+	// a subkey bound by another key's signature has an invalid binding signature
+	invalid.Subkeys = append(invalid.Subkeys, other.Subkeys[0])
+	for _, key := range []*openpgp.Entity{invalid, valid} {
+		if err := key.Serialize(&buffer); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys, skipped, err := ReadKeysSkipped(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || !bytes.Equal(keys[0].PrimaryKey.Fingerprint, valid.PrimaryKey.Fingerprint) {
+		t.Errorf("Expected only the valid key, got %d key(s)", len(keys))
+	}
+	if len(skipped) != 1 || !bytes.Equal(skipped[0].Fingerprint, invalid.PrimaryKey.Fingerprint) {
+		t.Errorf("Expected the key with invalid subkey binding to be skipped: %+v", skipped)
+	}
+}
+
+// eddsaKeys are fixtures of keys with EdDSA primary keys, as generated by GnuPG (legacy EdDSA) and by
+// go-crypto (Ed25519 and Ed448, as specified in RFC 9580).
+var eddsaKeys = []struct {
+	name        string
+	algorithm   packet.PublicKeyAlgorithm
+	fingerprint string
+	key         string
+}{
+	{"legacy EdDSA", packet.PubKeyAlgoEdDSA, "FF792D1F24A40E2DE003811FE99A54B2838CE518", `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatKEFBYJKwYBBAHaRw8BAQdAt578USF6q8Dq4r8ddFI6b1Uh5TZ1CaJAjhE0
+0rQHWgO0IUVkRFNBIEZpeHR1cmUgPGVkZHNhQGV4YW1wbGUub3JnPoiQBBMWCAA4
+FiEE/3ktHySkDi3gA4Ef6ZpUsoOM5RgFAmrShBQCGwMFCwkIBwIGFQoJCAsCBBYC
+AwECHgECF4AACgkQ6ZpUsoOM5RiXhwEAhgIcfSWBkLzo07KzAa90eJGgFd0eCpEx
+mBVCpD92iCIA/iC2TilIBppcx+g0f9LDJ7PIJRQaaj7p7ds4N6QoDnwG
+=d6gL
+-----END PGP PUBLIC KEY BLOCK-----`},
+	{"Ed25519", packet.PubKeyAlgoEd25519, "0D8F26C0226B6B788E403D205C3E350ECD37C266", `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+xiYEZVPxABu8ILvqA9QLc9DFrGXoCmSiqOP0t7rtAyKAkt3dOvvtqM0jQWxnbzI3
+IEZpeHR1cmUgPGFsZ28yN0BleGFtcGxlLm9yZz7CuQQTGwgAbwWCZVPxAAILBwkQ
+XD41Ds03wmY1FAAAAAAAHAAQc2FsdEBub3RhdGlvbnMub3BlbnBncGpzLm9yZ8cp
+wFeBVeAHYrmEzTRqZzkCFQgCFgACGQECmwMCHgEWIQQNjybAImtreI5APSBcPjUO
+zTfCZgAAW3m08kx/2m5LlunhJaMRmoU57LbEVVgE9xsFvif4XU8REuH/45WscHGM
+y+H6LGCOHkLoMEvkxw7FD+2pnn+uvxAGziYEZVPxABkA5cHT8a3g8IRhKHjelhVx
+gmSq45yChacbB1Eh/rpBFsKqBBgbCABgBYJlU/EACRBcPjUOzTfCZjUUAAAAAAAc
+ABBzYWx0QG5vdGF0aW9ucy5vcGVucGdwanMub3Jn3s/YH/bYIlOVZtksQoYgVAKb
+DBYhBA2PJsAia2t4jkA9IFw+NQ7NN8JmAACbrJeccLtH8Fl2YMbXOHATglHG6kyh
+7/Pqo3Z0WLeRl3YjzBxUgxv7twHzA7XC16nQ3Pp5KnksshHFsk/tSMNP7Ak=
+=eAsn
+-----END PGP PUBLIC KEY BLOCK-----`},
+	{"Ed448", packet.PubKeyAlgoEd448, "86CC42C58F4871AE49366B462B2EF033D55312D5", `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+xj8EZVPxABzZ6Dsq4DwIsj+z9n1QvGOV+boGTUBcN3FnKsdT8LFuSlotxDgtJWFQ
+NRG5XrTKXQXbjgPL0swYfADNI0FsZ28yOCBGaXh0dXJlIDxhbGdvMjhAZXhhbXBs
+ZS5vcmc+wsArBBMcCABvBYJlU/EAAgsHCRArLvAz1VMS1TUUAAAAAAAcABBzYWx0
+QG5vdGF0aW9ucy5vcGVucGdwanMub3Jnc+bdttr4aHnqU2H8VMg6GAIVCAIWAAIZ
+AQKbAwIeARYhBIbMQsWPSHGuSTZrRisu8DPVUxLVAADJW89MHbxk8ZO5UjOXUgwH
+6DO4kb1HR+iq4DEuaZc9239AwGT0/paf5JvzogNPPUCbvtTBuZ3BPTChgM2UXd1d
+UbFRuOCUuxEhyqRlyFedRfaCGA7bTXp6mrJ+d6av2BAVglfwDza8jGJW8zttW1Jz
+fDgcAM4+BGVT8QAaoYGtZG7bwON/ZphRgxkTSGjvubxAyC/JBlm9qIw15kHNYmhv
+lxF2GZRKKOwqTkwMP3Jd2vV2hxXCwBwEGBwIAGAFgmVT8QAJECsu8DPVUxLVNRQA
+AAAAABwAEHNhbHRAbm90YXRpb25zLm9wZW5wZ3Bqcy5vcmfxYWfIwnkU2nelBVcz
+bB24ApsMFiEEhsxCxY9Ica5JNmtGKy7wM9VTEtUAALWhXCOWfqx/iY1xLASykohz
+LyH+ySy3BrUOqWhm/dMIgqBNTswBicYXUwYdmY0F89s7pRitZY+PG+CAtiDEh0cJ
+/BorfcN/mV4wuY/xZgOCJPkdbqyLFxi416zKkpaxXUyAU+K+ZVLw8vun2qM+J1EC
+mhQA
+=YLbC
+-----END PGP PUBLIC KEY BLOCK-----`},
+}
+
+func TestReadKeysEdDSA(t *testing.T) {
+	for _, fixture := range eddsaKeys {
+		keys, err := ReadKeys(strings.NewReader(fixture.key))
+		if err != nil {
+			t.Fatalf("%s: %v", fixture.name, err)
+		}
+		if len(keys) != 1 {
+			t.Fatalf("%s: expected 1 key, got %d", fixture.name, len(keys))
+		}
+		if keys[0].PrimaryKey.PubKeyAlgo != fixture.algorithm {
+			t.Errorf("%s: unexpected algorithm %d", fixture.name, keys[0].PrimaryKey.PubKeyAlgo)
+		}
+		if fingerprint := fmt.Sprintf("%X", keys[0].PrimaryKey.Fingerprint); fingerprint != fixture.fingerprint {
+			t.Errorf("%s: unexpected fingerprint %s", fixture.name, fingerprint)
+		}
+	}
+}
+
+func TestReadKeyIDs(t *testing.T) {
+	input := "# comment\n0x1111111111111111\nkeyid:22222222222222AA\n\nnot-a-keyid\n3333333333333333"
+	keyids := ReadKeyIDs(bufio.NewReader(strings.NewReader(input)))
+	expected := []uint64{0x1111111111111111, 0x22222222222222AA, 0x3333333333333333}
+	if !reflect.DeepEqual(keyids, expected) {
+		t.Errorf("Unexpected key-ids: %X", keyids)
+	}
+}
+
+func TestWriteResolution(t *testing.T) {
+	key, other := generateKey(t), generateKey(t)
+	var out bytes.Buffer
+	WriteResolution(&out, 1, nil)
+	WriteResolution(&out, 2, []*openpgp.Entity{key})
+	WriteResolution(&out, 3, []*openpgp.Entity{key, other})
+	expected := fmt.Sprintf("0000000000000001 = noKey\n0000000000000002 = 0x%040X\n0000000000000003 = noKey\n",
+		key.PrimaryKey.Fingerprint)
+	if out.String() != expected {
+		t.Errorf("Unexpected output:\n%s", out.String())
+	}
+}
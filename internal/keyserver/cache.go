@@ -0,0 +1,124 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package keyserver
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/cobratbq/keysmap-tools/internal/keyring"
+)
+
+// notFoundDir is the subdirectory of the cache that records key-ids that the keyserver does not
+// have. As a subdirectory, it is ignored when the cache directory is loaded as keyring.
+const notFoundDir = "not-found"
+
+// Cache is a directory of keys, stored as `<FINGERPRINT>.asc` per primary key, such that the
+// directory can be used as keyring. Key-ids that were not found are recorded in a negative cache
+// that expires after a configurable duration, as keys may be published later.
+type Cache struct {
+	dir         string
+	negativeTTL time.Duration
+	keys        openpgp.EntityList
+	index       keyring.Index
+}
+
+// OpenCache opens the cache directory, creating it if necessary, and loads the cached keys.
+func OpenCache(dir string, negativeTTL time.Duration) (*Cache, error) {
+	if err := os.MkdirAll(filepath.Join(dir, notFoundDir), 0755); err != nil {
+		return nil, err
+	}
+	keys, err := keyring.Load(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir, negativeTTL: negativeTTL, keys: keys, index: keyring.NewIndex(keys)}, nil
+}
+
+// Lookup returns the cached keys that have the key-id for their primary key or one of their subkeys.
+func (c *Cache) Lookup(keyid uint64) []*openpgp.Entity {
+	return c.index.Lookup(keyid)
+}
+
+// NotFound indicates whether the key-id was recently not found on the keyserver.
+func (c *Cache) NotFound(keyid uint64) bool {
+	stat, err := os.Stat(c.notFoundPath(keyid))
+	return err == nil && time.Since(stat.ModTime()) < c.negativeTTL
+}
+
+// StoreNotFound records that the key-id was not found on the keyserver.
+func (c *Cache) StoreNotFound(keyid uint64) error {
+	return os.WriteFile(c.notFoundPath(keyid), nil, 0644)
+}
+
+// Store stores the key in the cache, replacing an earlier copy of the key, and removes the key-ids
+// of its primary key and subkeys from the negative cache.
+func (c *Cache) Store(key *openpgp.Entity) error {
+	var buffer bytes.Buffer
+	if err := keyring.WriteKeys(&buffer, openpgp.EntityList{key}); err != nil {
+		return err
+	}
+	destination := filepath.Join(c.dir, fmt.Sprintf("%X.asc", key.PrimaryKey.Fingerprint))
+	if err := os.WriteFile(destination+".part", buffer.Bytes(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(destination+".part", destination); err != nil {
+		return err
+	}
+	keys := openpgp.EntityList{key}
+	for _, existing := range c.keys {
+		if !bytes.Equal(existing.PrimaryKey.Fingerprint, key.PrimaryKey.Fingerprint) {
+			keys = append(keys, existing)
+		}
+	}
+	c.keys, c.index = keys, keyring.NewIndex(keys)
+	os.Remove(c.notFoundPath(key.PrimaryKey.KeyId))
+	for _, subkey := range key.Subkeys {
+		os.Remove(c.notFoundPath(subkey.PublicKey.KeyId))
+	}
+	return nil
+}
+
+func (c *Cache) notFoundPath(keyid uint64) string {
+	return filepath.Join(c.dir, notFoundDir, fmt.Sprintf("%016X", keyid))
+}
+
+// Fetch returns the keys with the key-id, either from the cache or from the keyserver. Only keys
+// that actually have the key-id are cached. ErrNotFound is returned if the key-id is not found,
+// including when it was recently not found according to the negative cache.
+func (c *Cache) Fetch(client *Client, keyid uint64) ([]*openpgp.Entity, error) {
+	if keys := c.Lookup(keyid); len(keys) > 0 {
+		return keys, nil
+	}
+	if c.NotFound(keyid) {
+		return nil, ErrNotFound
+	}
+	keys, err := client.Get(fmt.Sprintf("%016X", keyid))
+	if err == ErrNotFound {
+		return nil, c.notFound(keyid)
+	}
+	if err != nil {
+		return nil, err
+	}
+	matching := keyring.NewIndex(keys).Lookup(keyid)
+	if len(matching) == 0 {
+		return nil, c.notFound(keyid)
+	}
+	for _, key := range matching {
+		if err = c.Store(key); err != nil {
+			return nil, err
+		}
+	}
+	return c.Lookup(keyid), nil
+}
+
+func (c *Cache) notFound(keyid uint64) error {
+	if err := c.StoreNotFound(keyid); err != nil {
+		return err
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,64 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+// Package keyserver retrieves public keys from keyservers using the HKP protocol, and caches them
+// locally.
+package keyserver
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	io_ "github.com/cobratbq/goutils/std/io"
+	"github.com/cobratbq/keysmap-tools/internal/keyring"
+)
+
+// DefaultURL is the keyserver used if no keyserver is specified explicitly.
+const DefaultURL = "https://keyserver.ubuntu.com/"
+
+// ErrNotFound indicates that the keyserver does not have the requested key.
+var ErrNotFound = errors.New("key not found on keyserver")
+
+// Client retrieves keys from a keyserver using HKP.
+type Client struct {
+	server string
+	client *http.Client
+}
+
+// NewClient creates a client for the keyserver at base URL `server`. If server is empty, DefaultURL
+// is used.
+func NewClient(server string) *Client {
+	if server == "" {
+		server = DefaultURL
+	}
+	if !strings.HasSuffix(server, "/") {
+		server += "/"
+	}
+	return &Client{server: server, client: &http.Client{}}
+}
+
+// Get retrieves the keys matching `search`, a key-id or fingerprint in hexadecimal notation without
+// `0x` prefix. The keyserver decides which keys match, so callers must check that the keys are the
+// requested keys.
+func (c *Client) Get(search string) (openpgp.EntityList, error) {
+	query := url.Values{"op": {"get"}, "options": {"mr"}, "search": {"0x" + search}}
+	resp, err := c.client.Get(c.server + "pks/lookup?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer io_.CloseLogged(resp.Body, "Failed to close response body: %+v")
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d from keyserver", resp.StatusCode)
+	}
+	keys, err := keyring.ReadKeys(resp.Body)
+	if err == keyring.ErrNoKeys {
+		return nil, ErrNotFound
+	}
+	return keys, err
+}
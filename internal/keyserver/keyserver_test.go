@@ -0,0 +1,48 @@
+package keyserver
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/cobratbq/keysmap-tools/internal/keyserver/keyservertest"
+)
+
+func TestCacheFetch(t *testing.T) {
+	key, err := openpgp.NewEntity("Test", "", "test@example.org", &packet.Config{Algorithm: packet.PubKeyAlgoEd25519})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := keyservertest.NewServer(openpgp.EntityList{key})
+	defer server.Close()
+	client := NewClient(server.URL)
+	dir := t.TempDir()
+	cache, err := OpenCache(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		keys, err := cache.Fetch(client, key.PrimaryKey.KeyId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 1 || !bytes.Equal(keys[0].PrimaryKey.Fingerprint, key.PrimaryKey.Fingerprint) {
+			t.Fatalf("Unexpected keys: %v", keys)
+		}
+		if _, err = cache.Fetch(client, 0x0123456789ABCDEF); err != ErrNotFound {
+			t.Fatalf("Expected key not to be found, got: %v", err)
+		}
+	}
+	if server.Requests() != 2 {
+		t.Errorf("Expected keys and negative result to be cached, got %d requests", server.Requests())
+	}
+	// Keys are cached by subkey key-id too, and are loaded again from the cache directory.
+	if cache, err = OpenCache(dir, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if keys := cache.Lookup(key.Subkeys[0].PublicKey.KeyId); len(keys) != 1 {
+		t.Errorf("Expected cached key for subkey key-id, got: %v", keys)
+	}
+}
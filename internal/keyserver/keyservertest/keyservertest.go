@@ -0,0 +1,94 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+// Package keyservertest provides a local stand-in for an HKP keyserver, such that keyserver
+// interaction can be tested offline.
+package keyservertest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+)
+
+// Server is a fake keyserver that serves the keys it was created with, looked up by key-id or
+// fingerprint of the primary key or any subkey. Requests counts the lookup requests.
+type Server struct {
+	*httptest.Server
+	keys     openpgp.EntityList
+	mutex    sync.Mutex
+	requests int
+}
+
+// NewServer starts a fake keyserver serving `keys`. The caller must call Close when finished.
+func NewServer(keys openpgp.EntityList) *Server {
+	s := &Server{keys: keys}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.lookup))
+	return s
+}
+
+// Requests returns the number of lookup requests received.
+func (s *Server) Requests() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests
+}
+
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.requests++
+	s.mutex.Unlock()
+	query := r.URL.Query()
+	if r.URL.Path != "/pks/lookup" || query.Get("op") != "get" {
+		http.Error(w, "not implemented", http.StatusNotImplemented)
+		return
+	}
+	search := strings.TrimPrefix(strings.ToLower(query.Get("search")), "0x")
+	var matches []*openpgp.Entity
+	for _, key := range s.keys {
+		if matchKey(key, search) {
+			matches = append(matches, key)
+		}
+	}
+	if len(matches) == 0 {
+		http.Error(w, "No results found", http.StatusNotFound)
+		return
+	}
+	var buffer bytes.Buffer
+	armored, err := armor.Encode(&buffer, openpgp.PublicKeyType, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	for _, key := range matches {
+		if err = key.Serialize(armored); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	armored.Close()
+	w.Header().Set("Content-Type", "application/pgp-keys")
+	w.Write(buffer.Bytes())
+}
+
+func matchKey(key *openpgp.Entity, search string) bool {
+	if matchPublicKey(key.PrimaryKey.KeyId, key.PrimaryKey.Fingerprint, search) {
+		return true
+	}
+	for _, subkey := range key.Subkeys {
+		if matchPublicKey(subkey.PublicKey.KeyId, subkey.PublicKey.Fingerprint, search) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPublicKey(keyid uint64, fingerprint []byte, search string) bool {
+	return search == hex.EncodeToString(fingerprint) || search == fmt.Sprintf("%016x", keyid)
+}
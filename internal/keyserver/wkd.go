@@ -0,0 +1,117 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package keyserver
+
+import (
+	"crypto/sha1"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	io_ "github.com/cobratbq/goutils/std/io"
+	"github.com/cobratbq/keysmap-tools/internal/keyring"
+)
+
+// ErrInvalidEmail indicates that an email address cannot be used for WKD lookup.
+var ErrInvalidEmail = errors.New("invalid email address")
+
+// zbase32 is the z-base-32 encoding, as used by WKD to encode the hashed local part.
+var zbase32 = base32.NewEncoding("ybndrfg8ejkmcpqxot1uwisza345h769").WithPadding(base32.NoPadding)
+
+// WKD retrieves keys from a Web Key Directory, trying the advanced method before the direct method.
+type WKD struct {
+	client *http.Client
+}
+
+// NewWKD creates a WKD client. If client is nil, http.DefaultClient is used.
+func NewWKD(client *http.Client) *WKD {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &WKD{client: client}
+}
+
+// WKDURLs returns the URLs of the advanced and direct method, in that order, for the email address.
+func WKDURLs(email string) ([]string, error) {
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 || at == len(email)-1 {
+		return nil, ErrInvalidEmail
+	}
+	local, domain := email[:at], strings.ToLower(email[at+1:])
+	hash := sha1.Sum([]byte(strings.ToLower(local)))
+	encoded := zbase32.EncodeToString(hash[:])
+	query := url.Values{"l": {local}}.Encode()
+	return []string{
+		fmt.Sprintf("https://openpgpkey.%s/.well-known/openpgpkey/%s/hu/%s?%s", domain, domain, encoded, query),
+		fmt.Sprintf("https://%s/.well-known/openpgpkey/hu/%s?%s", domain, encoded, query),
+	}, nil
+}
+
+// Lookup retrieves the keys for the email address. Only keys with a user ID for the email address
+// are returned, as the WKD is authoritative for its domain only. ErrNotFound is returned if neither
+// method provides a key for the email address.
+func (w *WKD) Lookup(email string) (openpgp.EntityList, error) {
+	urls, err := WKDURLs(email)
+	if err != nil {
+		return nil, err
+	}
+	var failure error
+	for _, u := range urls {
+		keys, err := w.get(u)
+		if err != nil {
+			if failure == nil && err != ErrNotFound {
+				failure = err
+			}
+			continue
+		}
+		if keys = matchEmail(keys, email); len(keys) > 0 {
+			return keys, nil
+		}
+	}
+	if failure != nil {
+		return nil, failure
+	}
+	return nil, ErrNotFound
+}
+
+func (w *WKD) get(u string) (openpgp.EntityList, error) {
+	resp, err := w.client.Get(u)
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		// The advanced method's subdomain does not exist for most domains.
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer io_.CloseLogged(resp.Body, "Failed to close response body: %+v")
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d for %s", resp.StatusCode, u)
+	}
+	keys, err := keyring.ReadKeys(resp.Body)
+	if err == keyring.ErrNoKeys {
+		return nil, ErrNotFound
+	}
+	return keys, err
+}
+
+func matchEmail(keys openpgp.EntityList, email string) openpgp.EntityList {
+	var matching openpgp.EntityList
+	for _, key := range keys {
+		for _, identity := range key.Identities {
+			if identity.UserId != nil && strings.EqualFold(identity.UserId.Email, email) {
+				matching = append(matching, key)
+				break
+			}
+		}
+	}
+	return matching
+}
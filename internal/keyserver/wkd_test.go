@@ -0,0 +1,70 @@
+package keyserver
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+func TestWKDURLs(t *testing.T) {
+	urls, err := WKDURLs("Joe.Doe@Example.ORG")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"https://openpgpkey.example.org/.well-known/openpgpkey/example.org/hu/iy9q119eutrkn8s1mk4r39qejnbu3n5q?l=Joe.Doe",
+		"https://example.org/.well-known/openpgpkey/hu/iy9q119eutrkn8s1mk4r39qejnbu3n5q?l=Joe.Doe",
+	}
+	for i := range expected {
+		if urls[i] != expected[i] {
+			t.Errorf("Expected %s, got %s", expected[i], urls[i])
+		}
+	}
+	if _, err = WKDURLs("no-domain@"); err != ErrInvalidEmail {
+		t.Errorf("Expected invalid email, got: %v", err)
+	}
+}
+
+func TestWKDLookupDirect(t *testing.T) {
+	key, err := openpgp.NewEntity("Joe Doe", "", "joe.doe@example.com", &packet.Config{Algorithm: packet.PubKeyAlgoEd25519})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var serialized bytes.Buffer
+	if err = key.Serialize(&serialized); err != nil {
+		t.Fatal(err)
+	}
+	// Only the direct method is available: the domain serves the key, the `openpgpkey` subdomain
+	// does not.
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "example.com" || r.URL.Path != "/.well-known/openpgpkey/hu/iy9q119eutrkn8s1mk4r39qejnbu3n5q" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(serialized.Bytes())
+	}))
+	defer server.Close()
+	transport := server.Client().Transport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, _ string) (net.Conn, error) {
+		return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+	}
+	// The test certificate is issued for example.com.
+	transport.TLSClientConfig.ServerName = "example.com"
+	wkd := NewWKD(&http.Client{Transport: transport})
+	keys, err := wkd.Lookup("Joe.Doe@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || !bytes.Equal(keys[0].PrimaryKey.Fingerprint, key.PrimaryKey.Fingerprint) {
+		t.Errorf("Unexpected keys: %v", keys)
+	}
+	if _, err = wkd.Lookup("jane.doe@example.com"); err != ErrNotFound {
+		t.Errorf("Expected key not to be found, got: %v", err)
+	}
+}
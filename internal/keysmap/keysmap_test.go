@@ -0,0 +1,30 @@
+package keysmap
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	input := `org.example:lib:1.0 = 0x1111111111111111111111111111111111111111
+org.example:lib:1.1 = 0x1111111111111111111111111111111111111111
+org.example:lib:2.0 = noKey
+org.example:lib:2.1 = 0x2222222222222222222222222222222222222222
+org.example:tool:1.0 = noSig
+org.other:lib:1.0 = 0x3333333333333333333333333333333333333333
+org.other:util:1.0 = 0x3333333333333333333333333333333333333333
+`
+	expected := `org.example:lib:2.0 = noKey
+org.example:lib = 0x1111111111111111111111111111111111111111, 0x2222222222222222222222222222222222222222
+org.example:tool = noSig
+org.other = 0x3333333333333333333333333333333333333333
+`
+	entries, groups, identifiers := Read(bufio.NewReader(strings.NewReader(input)))
+	var output bytes.Buffer
+	Canonicalize(&output, entries, groups, identifiers)
+	if output.String() != expected {
+		t.Errorf("Unexpected canonical keysmap:\n%s", output.String())
+	}
+}
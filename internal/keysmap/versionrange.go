@@ -0,0 +1,111 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package keysmap
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidRange indicates that a version range does not follow Maven's version range syntax.
+var ErrInvalidRange = errors.New("invalid version range")
+
+// CompareVersions compares versions according to Maven's rules on version ordering. The result is
+// negative if a < b, positive if a > b, and 0 if the versions are equal.
+func CompareVersions(a, b string) int {
+	versions := []version{componentize(a), componentize(b)}
+	less := versionsorter(versions)
+	if less(0, 1) {
+		return -1
+	}
+	if less(1, 0) {
+		return 1
+	}
+	return 0
+}
+
+// VersionRange is a Maven version range, e.g. `[1.0,2.0)`, `[2.0,)` or `(,1.0],[1.2,)`. A version
+// is in the range if it satisfies any of its restrictions.
+type VersionRange []restriction
+
+type restriction struct {
+	lower          string
+	lowerInclusive bool
+	upper          string
+	upperInclusive bool
+}
+
+// ParseVersionRange parses a Maven version range. A single version in brackets, e.g. `[1.5]`,
+// matches exactly that version.
+func ParseVersionRange(spec string) (VersionRange, error) {
+	var r VersionRange
+	spec = strings.TrimSpace(spec)
+	for spec != "" {
+		if spec[0] != '[' && spec[0] != '(' {
+			return nil, ErrInvalidRange
+		}
+		end := strings.IndexAny(spec, "])")
+		if end < 0 {
+			return nil, ErrInvalidRange
+		}
+		bounds := strings.Split(spec[1:end], ",")
+		res := restriction{lowerInclusive: spec[0] == '[', upperInclusive: spec[end] == ']'}
+		switch len(bounds) {
+		case 1:
+			// exact version
+			if !res.lowerInclusive || !res.upperInclusive || strings.TrimSpace(bounds[0]) == "" {
+				return nil, ErrInvalidRange
+			}
+			res.lower, res.upper = strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[0])
+		case 2:
+			res.lower, res.upper = strings.TrimSpace(bounds[0]), strings.TrimSpace(bounds[1])
+			if res.lower != "" && res.upper != "" && CompareVersions(res.lower, res.upper) > 0 {
+				return nil, ErrInvalidRange
+			}
+		default:
+			return nil, ErrInvalidRange
+		}
+		r = append(r, res)
+		spec = strings.TrimSpace(spec[end+1:])
+		if spec == "" {
+			break
+		}
+		if spec[0] != ',' {
+			return nil, ErrInvalidRange
+		}
+		spec = strings.TrimSpace(spec[1:])
+		if spec == "" {
+			return nil, ErrInvalidRange
+		}
+	}
+	if len(r) == 0 {
+		return nil, ErrInvalidRange
+	}
+	return r, nil
+}
+
+// Contains indicates whether the version is within the range.
+func (r VersionRange) Contains(v string) bool {
+	for _, res := range r {
+		if res.contains(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func (res restriction) contains(v string) bool {
+	if res.lower != "" {
+		cmp := CompareVersions(v, res.lower)
+		if cmp < 0 || (cmp == 0 && !res.lowerInclusive) {
+			return false
+		}
+	}
+	if res.upper != "" {
+		cmp := CompareVersions(v, res.upper)
+		if cmp > 0 || (cmp == 0 && !res.upperInclusive) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,41 @@
+package keysmap
+
+import (
+	"testing"
+)
+
+func TestVersionRange(t *testing.T) {
+	testvalues := []struct {
+		spec     string
+		version  string
+		contains bool
+	}{
+		{"[2.0,)", "2.0", true},
+		{"[2.0,)", "1.9", false},
+		{"[2.0,)", "2.0-SNAPSHOT", false},
+		{"(2.0,)", "2.0", false},
+		{"(2.0,)", "2.0.1", true},
+		{"[1.0,2.0)", "1.5", true},
+		{"[1.0,2.0)", "2.0", false},
+		{"(,1.0]", "1.0", true},
+		{"(,1.0]", "1.0.1", false},
+		{"[1.5]", "1.5", true},
+		{"[1.5]", "1.5.1", false},
+		{"(,1.0],[1.2,)", "1.1", false},
+		{"(,1.0],[1.2,)", "1.3", true},
+	}
+	for _, v := range testvalues {
+		r, err := ParseVersionRange(v.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", v.spec, err)
+		}
+		if r.Contains(v.version) != v.contains {
+			t.Errorf("Expected %s in %s to be %v", v.version, v.spec, v.contains)
+		}
+	}
+	for _, spec := range []string{"", "2.0", "[2.0", "(1.5)", "[2.0,1.0]", "[1.0,2.0),"} {
+		if _, err := ParseVersionRange(spec); err != ErrInvalidRange {
+			t.Errorf("Expected %q to be invalid, got: %v", spec, err)
+		}
+	}
+}
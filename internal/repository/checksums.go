@@ -0,0 +1,120 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package repository
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	io_ "github.com/cobratbq/goutils/std/io"
+	"github.com/cobratbq/keysmap-tools/internal/checksum"
+)
+
+// ErrChecksumMismatch indicates that downloaded content does not match the checksum that the
+// repository publishes for it.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// SetVerifyChecksums configures whether downloads are verified against the checksum files that
+// repositories publish next to every file, e.g. `maven-metadata.xml.sha1`. Content that does not
+// match is not stored. Files without checksum files are stored unverified. Files from the local
+// repository are verified against the checksum files stored next to them.
+func (c *Client) SetVerifyChecksums(verify bool) {
+	c.checksums = verify
+}
+
+// verifyChecksum verifies the file at `path`, downloaded from `url`, against the strongest checksum
+// that is available for `url`. verifyChecksum returns the name of the algorithm that was used, or
+// the empty string if no checksum is available. A mismatch is considered temporary, as the next
+// attempt may not suffer the same corruption.
+func (c *Client) verifyChecksum(path, url string) (string, error) {
+	for _, algorithm := range checksum.Algorithms {
+		expected, err := c.fetchChecksum(url+"."+algorithm.Name, algorithm)
+		if err != nil {
+			return "", err
+		}
+		if expected == nil {
+			continue
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		actual, err := checksum.Sum(algorithm, f)
+		io_.CloseLogged(f, "Failed to close downloaded file: %+v")
+		if err != nil {
+			return "", err
+		}
+		if !bytes.Equal(actual, expected) {
+			return algorithm.Name, temporaryError{fmt.Errorf("%w: %s of %s is %x, expected %x",
+				ErrChecksumMismatch, algorithm.Name, url, actual, expected)}
+		}
+		return algorithm.Name, nil
+	}
+	return "", nil
+}
+
+// fetchChecksum fetches a checksum file. The digest is the first field of the file, which may be
+// followed by a file name as in the output of `sha1sum`. fetchChecksum returns nil if the checksum
+// file is unavailable, or does not contain a digest of the expected size. Any status that is not
+// retried, such as 404 or a 403 from a proxy that blocks the extension, means unavailable.
+func (c *Client) fetchChecksum(url string, algorithm checksum.Algorithm) ([]byte, error) {
+	content, _, err := c.fetch(url)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if _, retryable := retryDelay(err); !retryable {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseDigest(content, algorithm), nil
+}
+
+// parseDigest parses the digest from the content of a checksum file, or returns nil if the content
+// does not start with a digest of the expected size.
+func parseDigest(content []byte, algorithm checksum.Algorithm) []byte {
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return nil
+	}
+	digest, err := hex.DecodeString(fields[0])
+	if err != nil || len(digest) != algorithm.Size {
+		return nil
+	}
+	return digest
+}
+
+// verifyLocalChecksum verifies the content of a file in the local repository against the strongest
+// checksum file next to it, as Maven stores the checksum files of downloads, e.g. `.sha1`.
+// verifyLocalChecksum returns the name of the algorithm that was used, or the empty string if no
+// checksum is available.
+func verifyLocalChecksum(path string, content []byte) (string, error) {
+	for _, algorithm := range checksum.Algorithms {
+		sidecar, err := os.ReadFile(path + "." + algorithm.Name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		expected := parseDigest(sidecar, algorithm)
+		if expected == nil {
+			continue
+		}
+		actual, err := checksum.Sum(algorithm, bytes.NewReader(content))
+		if err != nil {
+			return "", err
+		}
+		if !bytes.Equal(actual, expected) {
+			return algorithm.Name, fmt.Errorf("%w: %s of %s is %x, expected %x",
+				ErrChecksumMismatch, algorithm.Name, path, actual, expected)
+		}
+		return algorithm.Name, nil
+	}
+	return "", nil
+}
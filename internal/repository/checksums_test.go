@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestDownloadVerifyChecksums(t *testing.T) {
+	root, destination := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		"good.txt":       "hello",
+		"good.txt.sha1":  "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d  good.txt\n",
+		"good.txt.md5":   "00000000000000000000000000000000",
+		"bad.txt":        "hello",
+		"bad.txt.sha256": "0000000000000000000000000000000000000000000000000000000000000000",
+		"bad.txt.sha1":   "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d",
+		"plain.txt":      "hello",
+		"plain.txt.sha1": "<html>not a checksum</html>",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var repositories Repositories
+	if err := repositories.Set("file://" + root); err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(repositories, RetryPolicy{})
+	client.SetVerifyChecksums(true)
+	outcome, err := client.Download(filepath.Join(destination, "good.txt"), "good.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Checksum != "sha1" {
+		t.Errorf("Expected verification with strongest available checksum, got: %q", outcome.Checksum)
+	}
+	if _, err = client.Download(filepath.Join(destination, "bad.txt"), "bad.txt"); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected checksum mismatch, got: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(destination, "bad.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected mismatching content not to be stored.")
+	}
+	if outcome, err = client.Download(filepath.Join(destination, "plain.txt"), "plain.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Checksum != "" {
+		t.Errorf("Expected no verification for malformed checksum, got: %q", outcome.Checksum)
+	}
+}
+
+func TestDownloadChecksumBlocked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch path.Ext(r.URL.Path) {
+		case ".sha512", ".sha256":
+			w.WriteHeader(http.StatusForbidden)
+		case ".sha1":
+			w.Write([]byte("aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"))
+		default:
+			w.Write([]byte("hello"))
+		}
+	}))
+	defer server.Close()
+	client := NewClient(Repositories{server.URL + "/"}, RetryPolicy{})
+	client.SetVerifyChecksums(true)
+	outcome, err := client.Download(filepath.Join(t.TempDir(), "file.txt"), "file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Checksum != "sha1" {
+		t.Errorf("Expected blocked checksums to be skipped, got: %q", outcome.Checksum)
+	}
+}
+
+func TestLocalRepositoryChecksums(t *testing.T) {
+	local, destination := t.TempDir(), t.TempDir()
+	files := map[string]string{
+		"good.txt":      "hello",
+		"good.txt.sha1": "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d",
+		"bad.txt":       "corrupted",
+		"bad.txt.sha1":  "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(local, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := NewClient(Repositories{"file://" + t.TempDir() + "/"}, RetryPolicy{})
+	if err := client.SetLocal(local); err != nil {
+		t.Fatal(err)
+	}
+	client.SetVerifyChecksums(true)
+	outcome, err := client.Download(filepath.Join(destination, "good.txt"), "good.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.Checksum != "sha1" {
+		t.Errorf("Expected verification against local checksum file, got: %q", outcome.Checksum)
+	}
+	if _, err = client.Download(filepath.Join(destination, "bad.txt"), "bad.txt"); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected checksum mismatch, got: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(destination, "bad.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected mismatching content not to be stored.")
+	}
+}
@@ -0,0 +1,167 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package repository
+
+import (
+	"encoding/xml"
+	"html"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// hrefPattern matches the link targets in an HTML directory listing.
+var hrefPattern = regexp.MustCompile(`(?i)<a\s[^>]*href\s*=\s*["']([^"']+)["']`)
+
+// ParseListing parses an HTML directory listing, as served at `listingURL`, into the names of its
+// entries. Subdirectories have a trailing slash. Only links to direct children of the listed
+// directory are considered, such that links to parent directories, sorting options and other pages
+// are ignored. This accepts both relative links, as used by Maven Central and most web servers, and
+// absolute links, as used by Nexus.
+func ParseListing(listingURL string, content []byte) ([]string, error) {
+	base, err := url.Parse(listingURL)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	var entries []string
+	seen := make(map[string]struct{})
+	for _, match := range hrefPattern.FindAllSubmatch(content, -1) {
+		ref, err := url.Parse(html.UnescapeString(string(match[1])))
+		if err != nil || ref.RawQuery != "" || ref.Fragment != "" {
+			continue
+		}
+		target := base.ResolveReference(ref)
+		if target.Scheme != base.Scheme || target.Host != base.Host || !strings.HasPrefix(target.Path, base.Path) {
+			continue
+		}
+		name := target.Path[len(base.Path):]
+		if trimmed := strings.TrimSuffix(name, "/"); trimmed == "" || strings.Contains(trimmed, "/") ||
+			trimmed == "." || trimmed == ".." {
+			continue
+		}
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			entries = append(entries, name)
+		}
+	}
+	sort.Strings(entries)
+	return entries, nil
+}
+
+// groupMetadata is the metadata of a group, which lists the plugins in the group, or of an
+// artifact. Only the fields needed to tell these apart are included.
+type groupMetadata struct {
+	ArtifactID string `xml:"artifactId"`
+	Plugins    []struct {
+		ArtifactID string `xml:"artifactId"`
+	} `xml:"plugins>plugin"`
+}
+
+// DiscoverArtifacts enumerates the artifacts, as `<groupID>:<artifactID>`, of the group and all
+// groups with the group as prefix, by crawling the repository's directory listings. A directory with
+// artifact-level metadata is an artifact. If the group's directory listing is not available, the
+// group-level metadata is used, which lists only plugins. Subdirectories that cannot be crawled, e.g.
+// because of unreadable metadata, are skipped and reported as `<path>: <error>`.
+func (c *Client) DiscoverArtifacts(groupID string) ([]string, []string, error) {
+	var artifacts, skipped []string
+	seen := make(map[string]struct{})
+	add := func(artifact string) {
+		if _, ok := seen[artifact]; !ok {
+			seen[artifact] = struct{}{}
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	skip := func(relpath string, err error) {
+		skipped = append(skipped, relpath+": "+err.Error())
+	}
+	err := c.discover(GroupPath(groupID), add, skip)
+	if err == ErrNotFound || err == errNotListing {
+		plugins, err := c.groupPlugins(GroupPath(groupID))
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, plugin := range plugins {
+			add(groupID + ":" + plugin)
+		}
+		return artifacts, skipped, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return artifacts, skipped, nil
+}
+
+// discover crawls the directory at relpath. An error is returned only if the directory itself
+// cannot be listed. Subdirectories that fail are skipped. Unreadable metadata is skipped, but the
+// subdirectories are still crawled, as only readable metadata identifies an artifact directory.
+func (c *Client) discover(relpath string, add func(string), skip func(string, error)) error {
+	entries, err := c.list(relpath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry != "maven-metadata.xml" {
+			continue
+		}
+		content, _, err := c.Fetch(path.Join(relpath, entry))
+		if err != nil {
+			// still crawl the subdirectories, as they may contain artifacts
+			skip(path.Join(relpath, entry), err)
+			break
+		}
+		var metadata groupMetadata
+		if err = xml.Unmarshal(content, &metadata); err != nil {
+			skip(path.Join(relpath, entry), err)
+			break
+		}
+		groupID := strings.ReplaceAll(path.Dir(relpath), "/", ".")
+		if len(metadata.Plugins) == 0 && metadata.ArtifactID == path.Base(relpath) {
+			// artifact directory: its subdirectories are versions
+			add(groupID + ":" + metadata.ArtifactID)
+			return nil
+		}
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry, "/") {
+			err := c.discover(path.Join(relpath, entry), add, skip)
+			if err != nil && err != ErrNotFound && err != errNotListing {
+				skip(path.Join(relpath, entry), err)
+			}
+		}
+	}
+	return nil
+}
+
+// list lists the entries of a directory in the repository.
+func (c *Client) list(relpath string) ([]string, error) {
+	content, outcome, err := c.Fetch(relpath + "/")
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(outcome.ContentType, "html") {
+		return nil, errNotListing
+	}
+	return ParseListing(outcome.URL, content)
+}
+
+// groupPlugins lists the artifactIDs of the plugins in the group-level metadata.
+func (c *Client) groupPlugins(relpath string) ([]string, error) {
+	content, _, err := c.Fetch(path.Join(relpath, "maven-metadata.xml"))
+	if err != nil {
+		return nil, err
+	}
+	var metadata groupMetadata
+	if err = xml.Unmarshal(content, &metadata); err != nil {
+		return nil, err
+	}
+	var plugins []string
+	for _, plugin := range metadata.Plugins {
+		plugins = append(plugins, plugin.ArtifactID)
+	}
+	return plugins, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseListing(t *testing.T) {
+	central := []byte(`<html><body><h1>org/example/</h1><hr/><pre id="contents">
+<a href="../">../</a>
+<a href="lib/" title="lib/">lib/</a>                       2023-01-01 10:00         -
+<a href="maven-metadata.xml" title="maven-metadata.xml">maven-metadata.xml</a>  2023-01-01 10:00  412
+</pre></body></html>`)
+	nexus := []byte(`<table>
+<tr><td><a href="https://nexus.example.org/content/repositories/releases/org/">Parent Directory</a></td></tr>
+<tr><td><a href="https://nexus.example.org/content/repositories/releases/org/example/lib/">lib/</a></td></tr>
+<tr><td><a href="https://nexus.example.org/content/repositories/releases/org/example/maven-metadata.xml">maven-metadata.xml</a></td></tr>
+<tr><td><a href="?C=N;O=D">Name</a></td></tr>
+</table>`)
+	expected := []string{"lib/", "maven-metadata.xml"}
+	entries, err := ParseListing("https://repo1.maven.org/maven2/org/example/", central)
+	if err != nil || !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Unexpected entries for Maven Central listing: %v, %v", entries, err)
+	}
+	entries, err = ParseListing("https://nexus.example.org/content/repositories/releases/org/example/", nexus)
+	if err != nil || !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Unexpected entries for Nexus listing: %v, %v", entries, err)
+	}
+}
+
+func TestDiscoverArtifacts(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"org/example/maven-metadata.xml":                      `<metadata><plugins><plugin><artifactId>example-maven-plugin</artifactId></plugin></plugins></metadata>`,
+		"org/example/lib/maven-metadata.xml":                  `<metadata><groupId>org.example</groupId><artifactId>lib</artifactId></metadata>`,
+		"org/example/lib/1.0/lib-1.0.pom":                     `<project/>`,
+		"org/example/broken/maven-metadata.xml":               `<metadata`,
+		"org/example/broken/app/maven-metadata.xml":           `<metadata><groupId>org.example.broken</groupId><artifactId>app</artifactId></metadata>`,
+		"org/example/sub/tool/maven-metadata.xml":             `<metadata><groupId>org.example.sub</groupId><artifactId>tool</artifactId></metadata>`,
+		"org/example/example-maven-plugin/maven-metadata.xml": `<metadata><groupId>org.example</groupId><artifactId>example-maven-plugin</artifactId></metadata>`,
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var repositories Repositories
+	if err := repositories.Set("file://" + root); err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(repositories, RetryPolicy{})
+	artifacts, skipped, err := client.DiscoverArtifacts("org.example")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || !strings.HasPrefix(skipped[0], "org/example/broken/maven-metadata.xml: ") {
+		t.Errorf("Expected unreadable metadata to be skipped: %v", skipped)
+	}
+	// artifacts below unreadable group-level metadata are still discovered
+	expected := []string{"org.example.broken:app", "org.example:example-maven-plugin", "org.example:lib", "org.example.sub:tool"}
+	if !reflect.DeepEqual(artifacts, expected) {
+		t.Errorf("Unexpected artifacts: %v", artifacts)
+	}
+}
@@ -0,0 +1,163 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package repository
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	io_ "github.com/cobratbq/goutils/std/io"
+)
+
+// errLocalMiss indicates that the local repository does not provide a file.
+var errLocalMiss = errors.New("not in local repository")
+
+// SetLocal configures a local Maven repository, e.g. `~/.m2/repository`, that is consulted before
+// the (remote) repositories. A leading `~/` is expanded to the user's home directory.
+func (c *Client) SetLocal(local string) error {
+	if strings.HasPrefix(local, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		local = filepath.Join(home, local[2:])
+	}
+	stat, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	if !stat.IsDir() {
+		return errors.New("local repository is not a directory: " + local)
+	}
+	c.local = local
+	return nil
+}
+
+// copyLocal copies the file at relpath from the local repository to destination. The local
+// repository stores metadata per remote repository, as `maven-metadata-<repository-id>.xml`, which
+// are merged. Files that were installed locally, according to `_remote.repositories`, are ignored,
+// as these were not published. If checksum verification is enabled, content that does not match a
+// checksum file in the local repository is not stored. errLocalMiss is returned if the local
+// repository does not provide the file.
+func (c *Client) copyLocal(destination, relpath string) (Outcome, error) {
+	source := filepath.Join(c.local, filepath.FromSlash(relpath))
+	outcome := Outcome{URL: "file://" + filepath.ToSlash(source), Timestamp: time.Now().UTC()}
+	var content []byte
+	var err error
+	if path.Base(relpath) == "maven-metadata.xml" {
+		content, err = mergeLocalMetadata(filepath.Dir(source), c.checksums)
+	} else if installedLocally(source) {
+		return outcome, errLocalMiss
+	} else if content, err = os.ReadFile(source); err == nil && c.checksums {
+		outcome.Checksum, err = verifyLocalChecksum(source, content)
+	}
+	if os.IsNotExist(err) || err == errLocalMiss {
+		return outcome, errLocalMiss
+	}
+	if err != nil {
+		return outcome, err
+	}
+	outcome.StatusCode, outcome.Size = 200, int64(len(content))
+	if err = os.WriteFile(destination+".part", content, 0644); err != nil {
+		os.Remove(destination + ".part")
+		return outcome, err
+	}
+	if err = os.Rename(destination+".part", destination); err != nil {
+		os.Remove(destination + ".part")
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+// mergeLocalMetadata merges the metadata of all remote repositories in the artifact directory.
+// `maven-metadata-local.xml` is excluded, as it lists versions that were installed locally. If
+// `verify` is set, each file is verified against its checksum file, if present.
+func mergeLocalMetadata(dir string, verify bool) ([]byte, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "maven-metadata-*.xml"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	var merged Metadata
+	seen := make(map[string]struct{})
+	found := false
+	for _, match := range matches {
+		if filepath.Base(match) == "maven-metadata-local.xml" {
+			continue
+		}
+		data, err := os.ReadFile(match)
+		if err != nil {
+			return nil, err
+		}
+		if verify {
+			if _, err = verifyLocalChecksum(match, data); err != nil {
+				return nil, err
+			}
+		}
+		var metadata Metadata
+		if err = xml.Unmarshal(data, &metadata); err != nil {
+			return nil, errors.New(match + ": " + err.Error())
+		}
+		found = true
+		merged.GroupID, merged.ArtifactID = metadata.GroupID, metadata.ArtifactID
+		if metadata.LastUpdated > merged.LastUpdated {
+			merged.LastUpdated, merged.Latest, merged.Release = metadata.LastUpdated, metadata.Latest, metadata.Release
+		}
+		for _, v := range metadata.Versions {
+			if _, ok := seen[v]; !ok {
+				seen[v] = struct{}{}
+				merged.Versions = append(merged.Versions, v)
+			}
+		}
+	}
+	if !found {
+		return nil, errLocalMiss
+	}
+	data, err := xml.MarshalIndent(&merged, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// installedLocally determines from `_remote.repositories` whether the file, or the file it signs,
+// was installed locally rather than downloaded from a remote repository. Without
+// `_remote.repositories` the origin is unknown, and the file is assumed to be downloaded.
+func installedLocally(source string) bool {
+	f, err := os.Open(filepath.Join(filepath.Dir(source), "_remote.repositories"))
+	if err != nil {
+		return false
+	}
+	defer io_.CloseLogged(f, "Failed to close _remote.repositories: %+v")
+	origins := make(map[string]string)
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			break
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// format: <filename>><repository-id>=
+		name, origin, ok := strings.Cut(line, ">")
+		if ok {
+			origins[name] = strings.TrimSuffix(origin, "=")
+		}
+	}
+	name := filepath.Base(source)
+	origin, ok := origins[name]
+	if !ok {
+		origin, ok = origins[strings.TrimSuffix(name, ".asc")]
+	}
+	return ok && origin == ""
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLocalRepository(t *testing.T) {
+	local, remote, destination := t.TempDir(), t.TempDir(), t.TempDir()
+	files := map[string]string{
+		"org/example/lib/maven-metadata-central.xml": `<metadata><groupId>org.example</groupId><artifactId>lib</artifactId>` +
+			`<versioning><release>1.0</release><versions><version>1.0</version></versions><lastUpdated>20230101000000</lastUpdated></versioning></metadata>`,
+		"org/example/lib/maven-metadata-other.xml": `<metadata><groupId>org.example</groupId><artifactId>lib</artifactId>` +
+			`<versioning><release>1.1</release><versions><version>1.0</version><version>1.1</version></versions><lastUpdated>20230201000000</lastUpdated></versioning></metadata>`,
+		"org/example/lib/maven-metadata-local.xml": `<metadata><groupId>org.example</groupId><artifactId>lib</artifactId>` +
+			`<versioning><versions><version>2.0-SNAPSHOT</version></versions></versioning></metadata>`,
+		"org/example/lib/1.0/lib-1.0.jar.asc":        "local signature",
+		"org/example/lib/1.0/lib-1.0.pom.asc":        "locally installed",
+		"org/example/lib/1.0/_remote.repositories":   "#NOTE: This is a Maven Resolver internal implementation file\nlib-1.0.jar>central=\nlib-1.0.pom>=\n",
+		"remote:org/example/lib/1.0/lib-1.0.pom.asc": "remote signature",
+	}
+	for name, content := range files {
+		root := local
+		if strings.HasPrefix(name, "remote:") {
+			root, name = remote, strings.TrimPrefix(name, "remote:")
+		}
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var repositories Repositories
+	if err := repositories.Set("file://" + remote); err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(repositories, RetryPolicy{})
+	if err := client.SetLocal(local); err != nil {
+		t.Fatal(err)
+	}
+	for relpath, expected := range map[string]string{
+		"org/example/lib/1.0/lib-1.0.jar.asc": "local signature",
+		"org/example/lib/1.0/lib-1.0.pom.asc": "remote signature",
+	} {
+		if _, err := client.Download(filepath.Join(destination, "file"), relpath); err != nil {
+			t.Fatal(err)
+		}
+		if content, err := os.ReadFile(filepath.Join(destination, "file")); err != nil || string(content) != expected {
+			t.Errorf("Expected %q for %s, got %q, %v", expected, relpath, content, err)
+		}
+	}
+	if _, err := client.Download(filepath.Join(destination, "metadata.xml"), MetadataPath("org.example", "lib")); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(destination, "metadata.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metadata Metadata
+	if err = xml.Unmarshal(data, &metadata); err != nil {
+		t.Fatal(err)
+	}
+	if metadata.Release != "1.1" || metadata.LastUpdated != "20230201000000" ||
+		!reflect.DeepEqual(metadata.Versions, []string{"1.0", "1.1"}) {
+		t.Errorf("Unexpected merged metadata: %+v", metadata)
+	}
+}
@@ -0,0 +1,24 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package repository
+
+import (
+	"encoding/xml"
+	"path"
+)
+
+// Metadata is the artifact-level `maven-metadata.xml`, listing the versions of an artifact.
+type Metadata struct {
+	XMLName     xml.Name `xml:"metadata"`
+	GroupID     string   `xml:"groupId"`
+	ArtifactID  string   `xml:"artifactId"`
+	Latest      string   `xml:"versioning>latest"`
+	Release     string   `xml:"versioning>release"`
+	Versions    []string `xml:"versioning>versions>version"`
+	LastUpdated string   `xml:"versioning>lastUpdated"`
+}
+
+// MetadataPath is the path of the artifact-level metadata, relative to the repository root.
+func MetadataPath(groupID, artifactID string) string {
+	return path.Join(GroupPath(groupID), artifactID, "maven-metadata.xml")
+}
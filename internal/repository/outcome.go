@@ -0,0 +1,31 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package repository
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// OutcomeSuffix is the suffix for the sidecar file that records the outcome of downloading a file.
+const OutcomeSuffix = ".outcome.json"
+
+// WriteOutcome records the outcome of downloading the file at `destination`.
+func WriteOutcome(destination string, outcome Outcome) error {
+	data, err := json.MarshalIndent(outcome, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(destination+OutcomeSuffix, append(data, '\n'), 0644)
+}
+
+// ReadOutcome reads the recorded outcome of downloading the file at `destination`.
+func ReadOutcome(destination string) (Outcome, error) {
+	var outcome Outcome
+	data, err := os.ReadFile(destination + OutcomeSuffix)
+	if err != nil {
+		return outcome, err
+	}
+	err = json.Unmarshal(data, &outcome)
+	return outcome, err
+}
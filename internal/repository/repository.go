@@ -0,0 +1,315 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+// Package repository provides access to Maven repositories, either remote over HTTP(S) or local
+// through `file://` URLs to a directory laid out like a Maven repository.
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	io_ "github.com/cobratbq/goutils/std/io"
+)
+
+// DefaultURL is the repository used if no repositories are specified explicitly.
+const DefaultURL = "https://repo1.maven.org/maven2/"
+
+// ErrNotFound indicates that none of the repositories provides the requested file.
+var ErrNotFound = errors.New("not found in any repository")
+
+// errNotListing indicates that a directory does not provide an HTML directory listing.
+var errNotListing = errors.New("not a directory listing")
+
+// StatusError indicates that a repository responded with an unexpected HTTP status code.
+type StatusError struct {
+	URL        string
+	StatusCode int
+	// RetryAfter is the delay requested by the server through the `Retry-After` header, if any.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code %d for %s", e.StatusCode, e.URL)
+}
+
+// Repositories is an ordered list of repository base URLs. Repositories implements flag.Value such
+// that it can be used for a repeatable command-line flag.
+type Repositories []string
+
+func (r *Repositories) String() string {
+	return strings.Join(*r, ",")
+}
+
+// Set appends a repository base URL. Supported schemes are `http`, `https` and `file`. A relative
+// path in a `file:` URL is resolved against the current working directory.
+func (r *Repositories) Set(value string) error {
+	u, err := url.Parse(value)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "http", "https":
+	case "file":
+		p := u.Path
+		if u.Opaque != "" {
+			p = u.Opaque
+		}
+		if p, err = filepath.Abs(u.Host + p); err != nil {
+			return err
+		}
+		value = "file://" + filepath.ToSlash(p)
+	default:
+		return errors.New("unsupported repository URL scheme: " + u.Scheme)
+	}
+	if !strings.HasSuffix(value, "/") {
+		value += "/"
+	}
+	*r = append(*r, value)
+	return nil
+}
+
+// Client downloads files from an ordered list of repositories, falling back to the next repository
+// if a file cannot be acquired from a repository. Temporary failures are retried according to the
+// retry policy before falling back. If a local repository is configured, files are read from the
+// local repository first.
+type Client struct {
+	repositories Repositories
+	retry        RetryPolicy
+	client       *http.Client
+	// local is the path of the local repository, if any.
+	local string
+	// checksums indicates whether downloads are verified against published checksums.
+	checksums bool
+}
+
+// NewClient creates a client for the specified repositories. If no repositories are specified,
+// DefaultURL is used.
+func NewClient(repositories Repositories, retry RetryPolicy) *Client {
+	if len(repositories) == 0 {
+		repositories = Repositories{DefaultURL}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	return &Client{repositories: repositories, retry: retry, client: &http.Client{Transport: transport}}
+}
+
+// Outcome records the result of a download.
+type Outcome struct {
+	URL         string    `json:"url"`
+	StatusCode  int       `json:"status"`
+	Timestamp   time.Time `json:"timestamp"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"contentType,omitempty"`
+	// ETag and LastModified are the validators of the downloaded content, for conditional requests.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	// Checksum is the algorithm of the published checksum that the content was verified with.
+	Checksum string `json:"checksum,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Download downloads the file at path `relpath`, relative to the repository root, to `destination`.
+// Repositories are tried in order. Download returns the outcome of the download, i.e. of the last
+// attempted repository. ErrNotFound is returned if no repository has the file. If any repository
+// failed for other reasons, the first of these errors is returned instead, as the file may exist
+// after all.
+func (c *Client) Download(destination, relpath string) (Outcome, error) {
+	return c.downloadConditionally(destination, relpath, nil)
+}
+
+// DownloadIfModified downloads the file like Download, but only if it was modified since the
+// previous download. The validators of the previous outcome are only used for the repository that
+// provided the previous download. If the file was not modified, the outcome has status code 304 (Not
+// Modified) and `destination` is left untouched.
+func (c *Client) DownloadIfModified(destination, relpath string, previous Outcome) (Outcome, error) {
+	return c.downloadConditionally(destination, relpath, &previous)
+}
+
+func (c *Client) downloadConditionally(destination, relpath string, previous *Outcome) (Outcome, error) {
+	if c.local != "" {
+		if outcome, err := c.copyLocal(destination, relpath); err != errLocalMiss {
+			return outcome, err
+		}
+	}
+	return c.tryRepositories(relpath, func(url string) (Outcome, error) {
+		return c.download(destination, url, previous)
+	})
+}
+
+// Fetch fetches the file at path `relpath`, relative to the repository root, into memory, trying
+// repositories in the same way as Download. Fetch is intended for small files, such as metadata and
+// directory listings.
+func (c *Client) Fetch(relpath string) ([]byte, Outcome, error) {
+	var content []byte
+	outcome, err := c.tryRepositories(relpath, func(url string) (Outcome, error) {
+		var err error
+		var outcome Outcome
+		content, outcome, err = c.fetch(url)
+		return outcome, err
+	})
+	return content, outcome, err
+}
+
+// Exists determines whether any of the repositories provides the file at path `relpath`, relative
+// to the repository root, without downloading it. Repositories are tried in the same way as
+// Download.
+func (c *Client) Exists(relpath string) (bool, error) {
+	_, err := c.tryRepositories(relpath, c.head)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// tryRepositories attempts a download from every repository in order, until a download succeeds.
+func (c *Client) tryRepositories(relpath string, download func(url string) (Outcome, error)) (Outcome, error) {
+	var outcome, failedOutcome Outcome
+	var failure error
+	for _, base := range c.repositories {
+		url := base + relpath
+		err := c.retry.retry(func() error {
+			var err error
+			outcome, err = download(url)
+			return err
+		})
+		if err == nil {
+			return outcome, nil
+		}
+		outcome.Error = err.Error()
+		if outcome.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if failure == nil {
+			failure, failedOutcome = err, outcome
+		}
+	}
+	if failure != nil {
+		return failedOutcome, failure
+	}
+	return outcome, ErrNotFound
+}
+
+// download downloads a file to a temporary file next to `destination`, that is renamed only after
+// the download completed successfully and, if enabled, the checksum was verified. This prevents
+// partial or corrupted downloads at destination. If the previous outcome is for the same URL, the
+// request is conditional on the file being modified.
+func (c *Client) download(destination, url string, previous *Outcome) (Outcome, error) {
+	outcome := Outcome{URL: url, Timestamp: time.Now().UTC()}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return outcome, err
+	}
+	conditional := previous != nil && previous.URL == url &&
+		(previous.StatusCode == http.StatusOK || previous.StatusCode == http.StatusNotModified)
+	if conditional && previous.ETag != "" {
+		req.Header.Set("If-None-Match", previous.ETag)
+	}
+	if conditional && previous.LastModified != "" {
+		req.Header.Set("If-Modified-Since", previous.LastModified)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return outcome, temporaryError{err}
+	}
+	defer io_.CloseLogged(resp.Body, "Failed to close response body: %+v")
+	outcome.StatusCode = resp.StatusCode
+	outcome.ContentType = resp.Header.Get("Content-Type")
+	outcome.ETag = resp.Header.Get("ETag")
+	outcome.LastModified = resp.Header.Get("Last-Modified")
+	if conditional && resp.StatusCode == http.StatusNotModified {
+		if outcome.ETag == "" {
+			outcome.ETag = previous.ETag
+		}
+		if outcome.LastModified == "" {
+			outcome.LastModified = previous.LastModified
+		}
+		outcome.Size = previous.Size
+		return outcome, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return outcome, &StatusError{URL: url, StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+	partial := destination + ".part"
+	f, err := os.Create(partial)
+	if err != nil {
+		return outcome, err
+	}
+	if outcome.Size, err = io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(partial)
+		// failure to copy is most likely due to an interrupted connection
+		return outcome, temporaryError{err}
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(partial)
+		return outcome, err
+	}
+	if c.checksums {
+		if outcome.Checksum, err = c.verifyChecksum(partial, url); err != nil {
+			os.Remove(partial)
+			return outcome, err
+		}
+	}
+	if err = os.Rename(partial, destination); err != nil {
+		os.Remove(partial)
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+func (c *Client) head(url string) (Outcome, error) {
+	outcome := Outcome{URL: url, Timestamp: time.Now().UTC()}
+	resp, err := c.client.Head(url)
+	if err != nil {
+		return outcome, temporaryError{err}
+	}
+	defer io_.CloseLogged(resp.Body, "Failed to close response body: %+v")
+	outcome.StatusCode = resp.StatusCode
+	outcome.ContentType = resp.Header.Get("Content-Type")
+	if resp.StatusCode != http.StatusOK {
+		return outcome, &StatusError{URL: url, StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+	return outcome, nil
+}
+
+// maxFetchSize limits the size of content that is fetched into memory.
+const maxFetchSize = 16 << 20
+
+func (c *Client) fetch(url string) ([]byte, Outcome, error) {
+	outcome := Outcome{URL: url, Timestamp: time.Now().UTC()}
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return nil, outcome, temporaryError{err}
+	}
+	defer io_.CloseLogged(resp.Body, "Failed to close response body: %+v")
+	outcome.StatusCode = resp.StatusCode
+	outcome.ContentType = resp.Header.Get("Content-Type")
+	if resp.StatusCode != http.StatusOK {
+		return nil, outcome, &StatusError{URL: url, StatusCode: resp.StatusCode,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"))}
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxFetchSize+1))
+	if err != nil {
+		return nil, outcome, temporaryError{err}
+	}
+	if len(content) > maxFetchSize {
+		return nil, outcome, errors.New("content too large: " + url)
+	}
+	outcome.Size = int64(len(content))
+	return content, outcome, nil
+}
+
+// GroupPath converts a groupID into its directory path in the repository.
+func GroupPath(groupID string) string {
+	return path.Join(strings.Split(groupID, ".")...)
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDownloadFallback(t *testing.T) {
+	first, second, destination := t.TempDir(), t.TempDir(), t.TempDir()
+	if err := os.MkdirAll(filepath.Join(second, "org", "example"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(second, "org", "example", "file.txt"), []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var repositories Repositories
+	for _, r := range []string{"file://" + first, "file://" + second} {
+		if err := repositories.Set(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := NewClient(repositories, RetryPolicy{})
+	outcome, err := client.Download(filepath.Join(destination, "file.txt"), "org/example/file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.URL != "file://"+second+"/org/example/file.txt" {
+		t.Errorf("Unexpected source URL: %s", outcome.URL)
+	}
+	if outcome.StatusCode != 200 || outcome.Size != 5 {
+		t.Errorf("Unexpected outcome: %+v", outcome)
+	}
+	if content, err := os.ReadFile(filepath.Join(destination, "file.txt")); err != nil || string(content) != "hello" {
+		t.Errorf("Unexpected content: %q, %v", content, err)
+	}
+	if outcome, err = client.Download(filepath.Join(destination, "missing.txt"), "org/example/missing.txt"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got: %v", err)
+	}
+	if outcome.StatusCode != 404 {
+		t.Errorf("Expected status code 404 for missing file, got: %d", outcome.StatusCode)
+	}
+	if _, err = os.Stat(filepath.Join(destination, "missing.txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected no file for missing download.")
+	}
+	if exists, err := client.Exists("org/example/file.txt"); err != nil || !exists {
+		t.Errorf("Expected file to exist: %v", err)
+	}
+	if exists, err := client.Exists("org/example/missing.txt"); err != nil || exists {
+		t.Errorf("Expected missing file not to exist: %v", err)
+	}
+}
+
+func TestRepositoriesSet(t *testing.T) {
+	var repositories Repositories
+	if err := repositories.Set("ftp://example.org/maven2"); err == nil {
+		t.Error("Expected failure for unsupported scheme.")
+	}
+	if err := repositories.Set("https://example.org/maven2"); err != nil {
+		t.Fatal(err)
+	}
+	if repositories[0] != "https://example.org/maven2/" {
+		t.Errorf("Expected trailing slash to be added: %s", repositories[0])
+	}
+}
+
+func TestDownloadRetry(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+	client := NewClient(Repositories{server.URL + "/"}, RetryPolicy{Retries: 2, Delay: time.Millisecond, MaxDelay: time.Millisecond})
+	if _, err := client.Download(filepath.Join(t.TempDir(), "file.txt"), "file.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts, got %d", attempts)
+	}
+	attempts = 0
+	client = NewClient(Repositories{server.URL + "/"}, RetryPolicy{Retries: 1, Delay: time.Millisecond, MaxDelay: time.Millisecond})
+	_, err := client.Download(filepath.Join(t.TempDir(), "file.txt"), "file.txt")
+	if statusErr, ok := err.(*StatusError); !ok || statusErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status error after exhausting retries, got: %v", err)
+	}
+	attempts = 0
+	tooLong := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.Header().Set("Retry-After", "86400")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer tooLong.Close()
+	client = NewClient(Repositories{tooLong.URL + "/"}, RetryPolicy{Retries: 2, Delay: time.Millisecond, MaxDelay: time.Second})
+	if _, err = client.Download(filepath.Join(t.TempDir(), "file.txt"), "file.txt"); err == nil || attempts != 1 {
+		t.Errorf("Expected failure without retrying for excessive Retry-After, got: %v after %d attempts", err, attempts)
+	}
+}
+
+func TestDownloadIfModified(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("metadata"))
+	}))
+	defer server.Close()
+	var repositories Repositories
+	if err := repositories.Set(server.URL); err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(repositories, RetryPolicy{})
+	destination := filepath.Join(t.TempDir(), "maven-metadata.xml")
+	outcome, err := client.DownloadIfModified(destination, "maven-metadata.xml", Outcome{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outcome.StatusCode != http.StatusOK || outcome.ETag != `"v1"` {
+		t.Fatalf("Unexpected outcome: %+v", outcome)
+	}
+	if err = os.Remove(destination); err != nil {
+		t.Fatal(err)
+	}
+	if outcome, err = client.DownloadIfModified(destination, "maven-metadata.xml", outcome); err != nil {
+		t.Fatal(err)
+	}
+	if outcome.StatusCode != http.StatusNotModified || outcome.ETag != `"v1"` {
+		t.Errorf("Unexpected outcome: %+v", outcome)
+	}
+	if _, err = os.Stat(destination); !os.IsNotExist(err) {
+		t.Errorf("Expected destination to be untouched if not modified.")
+	}
+}
@@ -0,0 +1,86 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package repository
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy determines whether and when a failed download is attempted again. Transport errors,
+// HTTP status 429 (Too Many Requests) and 5xx server errors are retried. The delay between attempts
+// starts at Delay and doubles with every attempt, up to MaxDelay. A `Retry-After` header takes
+// precedence if it requests a longer delay, but a server that requests a delay beyond MaxDelay fails
+// the download rather than stalling it.
+type RetryPolicy struct {
+	Retries  uint
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
+// DefaultRetryPolicy is a retry policy suitable for public repositories.
+var DefaultRetryPolicy = RetryPolicy{Retries: 4, Delay: time.Second, MaxDelay: 30 * time.Second}
+
+// temporaryError marks a failure that may not occur on a next attempt.
+type temporaryError struct {
+	error
+}
+
+func (e temporaryError) Unwrap() error {
+	return e.error
+}
+
+// retry calls `attempt` until it succeeds, fails permanently, or the retries are exhausted.
+func (p *RetryPolicy) retry(attempt func() error) error {
+	delay := p.Delay
+	for i := uint(0); ; i++ {
+		err := attempt()
+		if err == nil || i >= p.Retries {
+			return err
+		}
+		wait, ok := retryDelay(err)
+		if !ok || wait > p.MaxDelay {
+			return err
+		}
+		if wait < delay {
+			wait = delay
+		}
+		time.Sleep(wait)
+		if delay *= 2; delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+	}
+}
+
+// retryDelay determines whether `err` is eligible for retrying and the minimum delay as requested
+// by the server, if any.
+func retryDelay(err error) (time.Duration, bool) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= 500 {
+			return statusErr.RetryAfter, true
+		}
+		return 0, false
+	}
+	var tempErr temporaryError
+	return 0, errors.As(err, &tempErr)
+}
+
+// parseRetryAfter parses the value of a `Retry-After` header, which is either a number of seconds
+// or an HTTP date. Zero is returned if the value is absent or malformed.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package sbom
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidPurl indicates that a package URL is malformed.
+var ErrInvalidPurl = errors.New("invalid package URL")
+
+// ParsePurl parses a Maven package URL: `pkg:maven/<groupID>/<artifactID>@<version>?<qualifiers>`.
+// The qualifiers `type` and `classifier` are used. ParsePurl reports false for package URLs of
+// other package types.
+func ParsePurl(purl string) (Artifact, bool, error) {
+	var artifact Artifact
+	if !strings.HasPrefix(purl, "pkg:") {
+		return artifact, false, ErrInvalidPurl
+	}
+	remainder := strings.TrimLeft(purl[len("pkg:"):], "/")
+	if i := strings.IndexByte(remainder, '#'); i >= 0 {
+		remainder = remainder[:i]
+	}
+	var qualifiers url.Values
+	if i := strings.IndexByte(remainder, '?'); i >= 0 {
+		var err error
+		if qualifiers, err = url.ParseQuery(remainder[i+1:]); err != nil {
+			return artifact, false, ErrInvalidPurl
+		}
+		remainder = remainder[:i]
+	}
+	segments := strings.Split(remainder, "/")
+	if !strings.EqualFold(segments[0], "maven") {
+		return artifact, false, nil
+	}
+	if len(segments) != 3 {
+		return artifact, false, ErrInvalidPurl
+	}
+	name := segments[2]
+	if i := strings.LastIndexByte(name, '@'); i >= 0 {
+		name, artifact.Version = name[:i], name[i+1:]
+	}
+	var err error
+	if artifact.GroupID, err = url.PathUnescape(segments[1]); err != nil {
+		return artifact, false, ErrInvalidPurl
+	}
+	if artifact.ArtifactID, err = url.PathUnescape(name); err != nil {
+		return artifact, false, ErrInvalidPurl
+	}
+	if artifact.Version, err = url.PathUnescape(artifact.Version); err != nil {
+		return artifact, false, ErrInvalidPurl
+	}
+	if artifact.GroupID == "" || artifact.ArtifactID == "" {
+		return artifact, false, ErrInvalidPurl
+	}
+	artifact.Type = qualifiers.Get("type")
+	artifact.Classifier = qualifiers.Get("classifier")
+	return artifact, true, nil
+}
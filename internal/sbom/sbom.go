@@ -0,0 +1,133 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+// Package sbom extracts Maven artifacts from software bills of materials: CycloneDX JSON, and SPDX
+// in either JSON or tag-value format. Artifacts are identified by their package URLs (purls).
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+)
+
+// ErrUnsupportedFormat indicates that the content is not a supported SBOM format.
+var ErrUnsupportedFormat = errors.New("unsupported SBOM format")
+
+// Artifact is a Maven artifact as identified by a package URL. Type and Classifier are empty if not
+// specified.
+type Artifact struct {
+	GroupID    string
+	ArtifactID string
+	Version    string
+	Type       string
+	Classifier string
+}
+
+type cyclonedxComponent struct {
+	Purl       string               `json:"purl"`
+	Components []cyclonedxComponent `json:"components"`
+}
+
+type document struct {
+	// CycloneDX
+	BOMFormat  string               `json:"bomFormat"`
+	Components []cyclonedxComponent `json:"components"`
+	// SPDX
+	SPDXVersion string `json:"spdxVersion"`
+	Packages    []struct {
+		ExternalRefs []struct {
+			ReferenceType    string `json:"referenceType"`
+			ReferenceLocator string `json:"referenceLocator"`
+		} `json:"externalRefs"`
+	} `json:"packages"`
+}
+
+// ReadFile reads the SBOM file and extracts its Maven artifacts.
+func ReadFile(path string) ([]Artifact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Read(data)
+}
+
+// Read extracts the distinct Maven artifacts, in order of appearance, from an SBOM. Package URLs of
+// other package types are ignored.
+func Read(data []byte) ([]Artifact, error) {
+	purls, err := extractPurls(data)
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []Artifact
+	seen := make(map[Artifact]struct{})
+	for _, purl := range purls {
+		artifact, maven, err := ParsePurl(purl)
+		if err != nil {
+			return nil, errors.New(purl + ": " + err.Error())
+		}
+		if _, ok := seen[artifact]; !maven || ok {
+			continue
+		}
+		seen[artifact] = struct{}{}
+		artifacts = append(artifacts, artifact)
+	}
+	return artifacts, nil
+}
+
+func extractPurls(data []byte) ([]string, error) {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("{")) {
+		return extractTagValuePurls(trimmed)
+	}
+	var doc document
+	if err := json.Unmarshal(trimmed, &doc); err != nil {
+		return nil, err
+	}
+	var purls []string
+	switch {
+	case doc.BOMFormat == "CycloneDX":
+		purls = appendComponentPurls(purls, doc.Components)
+	case doc.SPDXVersion != "":
+		for _, pkg := range doc.Packages {
+			for _, ref := range pkg.ExternalRefs {
+				if ref.ReferenceType == "purl" {
+					purls = append(purls, ref.ReferenceLocator)
+				}
+			}
+		}
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	return purls, nil
+}
+
+// appendComponentPurls appends the package URLs of the components, including nested components.
+func appendComponentPurls(purls []string, components []cyclonedxComponent) []string {
+	for _, component := range components {
+		if component.Purl != "" {
+			purls = append(purls, component.Purl)
+		}
+		purls = appendComponentPurls(purls, component.Components)
+	}
+	return purls
+}
+
+// extractTagValuePurls extracts package URLs from an SPDX tag-value document, from lines
+// `ExternalRef: PACKAGE-MANAGER purl <purl>`.
+func extractTagValuePurls(data []byte) ([]string, error) {
+	if !bytes.HasPrefix(data, []byte("SPDXVersion:")) {
+		return nil, ErrUnsupportedFormat
+	}
+	var purls []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 4 && fields[0] == "ExternalRef:" && fields[2] == "purl" {
+			purls = append(purls, fields[3])
+		}
+	}
+	return purls, scanner.Err()
+}
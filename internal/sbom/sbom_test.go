@@ -0,0 +1,55 @@
+package sbom
+
+import (
+	"testing"
+)
+
+func TestReadCycloneDX(t *testing.T) {
+	data := []byte(`{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "components": [
+    {"purl": "pkg:maven/org.example/lib@1.0?type=jar", "components": [
+      {"purl": "pkg:maven/org.example/lib@1.0?classifier=sources&type=jar"}
+    ]},
+    {"purl": "pkg:npm/%40angular/core@16.0.0"},
+    {"purl": "pkg:maven/org.example/lib@1.0?type=jar"},
+    {"name": "no-purl"}
+  ]
+}`)
+	expected := []Artifact{
+		{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Type: "jar"},
+		{GroupID: "org.example", ArtifactID: "lib", Version: "1.0", Type: "jar", Classifier: "sources"},
+	}
+	checkArtifacts(t, data, expected)
+}
+
+func TestReadSPDX(t *testing.T) {
+	expected := []Artifact{{GroupID: "org.example", ArtifactID: "app", Version: "2.0-rc1", Type: "war"}}
+	checkArtifacts(t, []byte(`{
+  "spdxVersion": "SPDX-2.3",
+  "packages": [{"externalRefs": [
+    {"referenceCategory": "PACKAGE-MANAGER", "referenceType": "purl", "referenceLocator": "pkg:maven/org.example/app@2.0-rc1?type=war"}
+  ]}]
+}`), expected)
+	checkArtifacts(t, []byte(`SPDXVersion: SPDX-2.3
+PackageName: app
+ExternalRef: PACKAGE-MANAGER purl pkg:maven/org.example/app@2.0-rc1?type=war
+`), expected)
+}
+
+func checkArtifacts(t *testing.T, data []byte, expected []Artifact) {
+	t.Helper()
+	artifacts, err := Read(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(artifacts) != len(expected) {
+		t.Fatalf("Expected %d artifacts, got: %+v", len(expected), artifacts)
+	}
+	for i := range expected {
+		if artifacts[i] != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], artifacts[i])
+		}
+	}
+}
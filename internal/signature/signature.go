@@ -0,0 +1,153 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+// Package signature extracts information from armored (detached) PGP signatures.
+package signature
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	io_ "github.com/cobratbq/goutils/std/io"
+	gocryptoarmor "golang.org/x/crypto/openpgp/armor"
+	gocryptoerrors "golang.org/x/crypto/openpgp/errors"
+	gocryptopacket "golang.org/x/crypto/openpgp/packet"
+)
+
+// ErrNoIssuer indicates that a signature does not identify its issuer.
+var ErrNoIssuer = errors.New("signature does not identify its issuer")
+
+// ErrNoSignatures indicates that the armored content does not contain any signature packets.
+var ErrNoSignatures = errors.New("no signatures found")
+
+// Issuer identifies the issuer of a signature. Fingerprint is only available if the signature
+// carries the Issuer Fingerprint subpacket, which is never the case for v3 (legacy) signatures.
+type Issuer struct {
+	KeyID       uint64
+	Fingerprint []byte
+	// SignerUserID is the user ID that the signer declared to sign with, if any.
+	SignerUserID string
+}
+
+// ReadIssuers reads an armored signature block and extracts the issuers of all signatures in the
+// block, as a detached signature may contain signatures of multiple signers. io.EOF is returned if
+// content is empty, i.e. there is no signature.
+func ReadIssuers(content []byte) ([]Issuer, error) {
+	signatures, legacySignatures, err := readSignatures(content)
+	if err != nil {
+		return nil, err
+	}
+	issuers := make([]Issuer, 0, len(signatures)+len(legacySignatures))
+	for _, sig := range signatures {
+		issuer := Issuer{KeyID: *sig.IssuerKeyId, Fingerprint: sig.IssuerFingerprint}
+		if sig.SignerUserId != nil {
+			issuer.SignerUserID = *sig.SignerUserId
+		}
+		issuers = append(issuers, issuer)
+	}
+	for _, sig := range legacySignatures {
+		issuers = append(issuers, Issuer{KeyID: sig.IssuerKeyId})
+	}
+	return issuers, nil
+}
+
+// readSignatures reads all signatures from an armored signature block. Legacy (v3) signatures are
+// returned separately, as these are read with golang.org/x/crypto.
+func readSignatures(content []byte) ([]*packet.Signature, []*gocryptopacket.SignatureV3, error) {
+	signatures, legacy, err := readPackets(bytes.NewReader(content))
+	if err != nil {
+		return nil, nil, err
+	}
+	var legacySignatures []*gocryptopacket.SignatureV3
+	if legacy {
+		if legacySignatures, err = readLegacySignaturePackets(bytes.NewReader(content)); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(signatures) == 0 && len(legacySignatures) == 0 {
+		return nil, nil, ErrNoSignatures
+	}
+	return signatures, legacySignatures, nil
+}
+
+// readPackets reads signature packets. ProtonMail/go-crypto cannot work with SignatureV3 packets
+// (legacy format). readPackets indicates whether unsupported packets were encountered, which then
+// need to be processed as legacy packets. ProtonMail/go-crypto does support EdDSA (legacy,
+// algorithm 22) and Ed25519/Ed448 (RFC 9580, algorithms 27 and 28) signatures.
+func readPackets(in io.Reader) ([]*packet.Signature, bool, error) {
+	block, err := armor.Decode(in)
+	if err != nil {
+		return nil, false, err
+	}
+	defer io_.Discard(block.Body)
+	var signatures []*packet.Signature
+	legacy := false
+	reader := packet.NewReader(block.Body)
+	for {
+		pkt, err := reader.NextWithUnsupported()
+		if err == io.EOF {
+			return signatures, legacy, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to extract signature body: %w", err)
+		}
+		switch sig := pkt.(type) {
+		case *packet.Signature:
+			if sig.IssuerKeyId == nil {
+				// v6 signatures are only required to carry the issuer fingerprint. ProtonMail/go-crypto
+				// derives the key-id from it, so absence of both means the issuer is not identified.
+				return nil, false, ErrNoIssuer
+			}
+			signatures = append(signatures, sig)
+		case *packet.Compressed:
+			if err = reader.Push(sig.Body); err != nil {
+				return nil, false, err
+			}
+		case *packet.UnsupportedPacket:
+			legacy = true
+		default:
+			return nil, false, fmt.Errorf("unsupported packet type: %T", sig)
+		}
+	}
+}
+
+// readLegacySignaturePackets reads openpgp signatures. readLegacySignaturePackets exists to handle
+// SignatureV3, the old signature format that ProtonMail/go-crypto does not support. Only the
+// SignatureV3 packets are processed, as other signatures are processed by ProtonMail/go-crypto.
+func readLegacySignaturePackets(in io.Reader) ([]*gocryptopacket.SignatureV3, error) {
+	block, err := gocryptoarmor.Decode(in)
+	if err != nil {
+		return nil, err
+	}
+	defer io_.Discard(block.Body)
+	var signatures []*gocryptopacket.SignatureV3
+	reader := gocryptopacket.NewReader(block.Body)
+	for {
+		pkt, err := reader.Next()
+		if err == io.EOF {
+			return signatures, nil
+		}
+		if _, ok := err.(gocryptoerrors.UnsupportedError); ok {
+			// e.g. signatures with EdDSA keys, which are processed by ProtonMail/go-crypto
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract signature body: %w", err)
+		}
+		switch sig := pkt.(type) {
+		case *gocryptopacket.Signature:
+			// processed by ProtonMail/go-crypto
+		case *gocryptopacket.SignatureV3:
+			signatures = append(signatures, sig)
+		case *gocryptopacket.Compressed:
+			if err = reader.Push(sig.Body); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported packet type: %T", sig)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package signature
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+func TestReadIssuersMultipleSignatures(t *testing.T) {
+	var signatures bytes.Buffer
+	var expected []*packet.PublicKey
+	for _, algorithm := range []packet.PublicKeyAlgorithm{packet.PubKeyAlgoEd25519, packet.PubKeyAlgoEdDSA} {
+		entity, err := openpgp.NewEntity("Test", "", "test@example.org", &packet.Config{Algorithm: algorithm})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = openpgp.DetachSign(&signatures, entity, bytes.NewReader([]byte("content")), nil); err != nil {
+			t.Fatal(err)
+		}
+		expected = append(expected, entity.PrimaryKey)
+	}
+	var armored bytes.Buffer
+	w, err := armor.Encode(&armored, openpgp.SignatureType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write(signatures.Bytes())
+	w.Close()
+	issuers, err := ReadIssuers(armored.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issuers) != len(expected) {
+		t.Fatalf("Expected %d issuers, got %d", len(expected), len(issuers))
+	}
+	for i, key := range expected {
+		if issuers[i].KeyID != key.KeyId || !bytes.Equal(issuers[i].Fingerprint, key.Fingerprint) {
+			t.Errorf("Expected issuer %016X/%X, got %016X/%X", key.KeyId, key.Fingerprint,
+				issuers[i].KeyID, issuers[i].Fingerprint)
+		}
+	}
+}
+
+func TestReadIssuersEmpty(t *testing.T) {
+	if _, err := ReadIssuers(nil); err != io.EOF {
+		t.Errorf("Expected io.EOF for empty content, got: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	signer, err := openpgp.NewEntity("Signer", "", "signer@example.org", &packet.Config{Algorithm: packet.PubKeyAlgoEd25519})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := openpgp.NewEntity("Other", "", "other@example.org", &packet.Config{Algorithm: packet.PubKeyAlgoEd25519})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var armored bytes.Buffer
+	if err = openpgp.ArmoredDetachSign(&armored, signer, bytes.NewReader([]byte("content")), nil); err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]struct {
+		content string
+		keys    openpgp.EntityList
+		verdict Verdict
+	}{
+		"good":        {"content", openpgp.EntityList{other, signer}, Good},
+		"bad":         {"tampered", openpgp.EntityList{signer}, Bad},
+		"unknown key": {"content", openpgp.EntityList{other}, UnknownKey},
+	}
+	for name, test := range tests {
+		verifications, err := Verify(bytes.NewReader([]byte(test.content)), armored.Bytes(), test.keys)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(verifications) != 1 || verifications[0].Verdict != test.verdict {
+			t.Errorf("%s: unexpected verifications: %+v", name, verifications)
+		}
+		if test.verdict != UnknownKey && verifications[0].Signer != signer {
+			t.Errorf("%s: expected signer to be identified", name)
+		}
+	}
+}
+
+// eddsaSignatures are fixtures of signatures of "hello\n" by keys with EdDSA primary keys, as
+// generated by GnuPG (legacy EdDSA) and by go-crypto (Ed25519 and Ed448, as specified in RFC 9580).
+var eddsaSignatures = []struct {
+	name        string
+	fingerprint string
+	key         string
+	signature   string
+}{
+	{"legacy EdDSA", "FF792D1F24A40E2DE003811FE99A54B2838CE518", `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+mDMEatKEFBYJKwYBBAHaRw8BAQdAt578USF6q8Dq4r8ddFI6b1Uh5TZ1CaJAjhE0
+0rQHWgO0IUVkRFNBIEZpeHR1cmUgPGVkZHNhQGV4YW1wbGUub3JnPoiQBBMWCAA4
+FiEE/3ktHySkDi3gA4Ef6ZpUsoOM5RgFAmrShBQCGwMFCwkIBwIGFQoJCAsCBBYC
+AwECHgECF4AACgkQ6ZpUsoOM5RiXhwEAhgIcfSWBkLzo07KzAa90eJGgFd0eCpEx
+mBVCpD92iCIA/iC2TilIBppcx+g0f9LDJ7PIJRQaaj7p7ds4N6QoDnwG
+=d6gL
+-----END PGP PUBLIC KEY BLOCK-----`, `-----BEGIN PGP SIGNATURE-----
+
+iHUEABYIAB0WIQT/eS0fJKQOLeADgR/pmlSyg4zlGAUCatKEFAAKCRDpmlSyg4zl
+GI99AP9uIfGN0ZGkGJezOi1Cg59j5y7lKhtDDu7pbvLYVPdxvgEAmXT6jThyher8
+47sjk1NsxpdWNNwKE0/PntrqDE48+Q8=
+=KBXM
+-----END PGP SIGNATURE-----`},
+	{"Ed25519", "0D8F26C0226B6B788E403D205C3E350ECD37C266", `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+xiYEZVPxABu8ILvqA9QLc9DFrGXoCmSiqOP0t7rtAyKAkt3dOvvtqM0jQWxnbzI3
+IEZpeHR1cmUgPGFsZ28yN0BleGFtcGxlLm9yZz7CuQQTGwgAbwWCZVPxAAILBwkQ
+XD41Ds03wmY1FAAAAAAAHAAQc2FsdEBub3RhdGlvbnMub3BlbnBncGpzLm9yZ8cp
+wFeBVeAHYrmEzTRqZzkCFQgCFgACGQECmwMCHgEWIQQNjybAImtreI5APSBcPjUO
+zTfCZgAAW3m08kx/2m5LlunhJaMRmoU57LbEVVgE9xsFvif4XU8REuH/45WscHGM
+y+H6LGCOHkLoMEvkxw7FD+2pnn+uvxAGziYEZVPxABkA5cHT8a3g8IRhKHjelhVx
+gmSq45yChacbB1Eh/rpBFsKqBBgbCABgBYJlU/EACRBcPjUOzTfCZjUUAAAAAAAc
+ABBzYWx0QG5vdGF0aW9ucy5vcGVucGdwanMub3Jn3s/YH/bYIlOVZtksQoYgVAKb
+DBYhBA2PJsAia2t4jkA9IFw+NQ7NN8JmAACbrJeccLtH8Fl2YMbXOHATglHG6kyh
+7/Pqo3Z0WLeRl3YjzBxUgxv7twHzA7XC16nQ3Pp5KnksshHFsk/tSMNP7Ak=
+=eAsn
+-----END PGP PUBLIC KEY BLOCK-----`, `-----BEGIN PGP SIGNATURE-----
+
+wqcEABsIAF0FgmVT8QAJEFw+NQ7NN8JmNRQAAAAAABwAEHNhbHRAbm90YXRpb25z
+Lm9wZW5wZ3Bqcy5vcmeWcu++OWW/TDO96eIXr6MBFiEEDY8mwCJra3iOQD0gXD41
+Ds03wmYAAIo1vqB6x25k7IrEDFl6AteYMd/G8ROqqmjtICwVa2WKCwieABFH9KKo
+9na77zAIFFU+kl+df2DAJoboLcM4PqZuAw==
+=hLRF
+-----END PGP SIGNATURE-----`},
+	{"Ed448", "86CC42C58F4871AE49366B462B2EF033D55312D5", `-----BEGIN PGP PUBLIC KEY BLOCK-----
+
+xj8EZVPxABzZ6Dsq4DwIsj+z9n1QvGOV+boGTUBcN3FnKsdT8LFuSlotxDgtJWFQ
+NRG5XrTKXQXbjgPL0swYfADNI0FsZ28yOCBGaXh0dXJlIDxhbGdvMjhAZXhhbXBs
+ZS5vcmc+wsArBBMcCABvBYJlU/EAAgsHCRArLvAz1VMS1TUUAAAAAAAcABBzYWx0
+QG5vdGF0aW9ucy5vcGVucGdwanMub3Jnc+bdttr4aHnqU2H8VMg6GAIVCAIWAAIZ
+AQKbAwIeARYhBIbMQsWPSHGuSTZrRisu8DPVUxLVAADJW89MHbxk8ZO5UjOXUgwH
+6DO4kb1HR+iq4DEuaZc9239AwGT0/paf5JvzogNPPUCbvtTBuZ3BPTChgM2UXd1d
+UbFRuOCUuxEhyqRlyFedRfaCGA7bTXp6mrJ+d6av2BAVglfwDza8jGJW8zttW1Jz
+fDgcAM4+BGVT8QAaoYGtZG7bwON/ZphRgxkTSGjvubxAyC/JBlm9qIw15kHNYmhv
+lxF2GZRKKOwqTkwMP3Jd2vV2hxXCwBwEGBwIAGAFgmVT8QAJECsu8DPVUxLVNRQA
+AAAAABwAEHNhbHRAbm90YXRpb25zLm9wZW5wZ3Bqcy5vcmfxYWfIwnkU2nelBVcz
+bB24ApsMFiEEhsxCxY9Ica5JNmtGKy7wM9VTEtUAALWhXCOWfqx/iY1xLASykohz
+LyH+ySy3BrUOqWhm/dMIgqBNTswBicYXUwYdmY0F89s7pRitZY+PG+CAtiDEh0cJ
+/BorfcN/mV4wuY/xZgOCJPkdbqyLFxi416zKkpaxXUyAU+K+ZVLw8vun2qM+J1EC
+mhQA
+=YLbC
+-----END PGP PUBLIC KEY BLOCK-----`, `-----BEGIN PGP SIGNATURE-----
+
+wsAZBAAcCABdBYJlU/EACRArLvAz1VMS1TUUAAAAAAAcABBzYWx0QG5vdGF0aW9u
+cy5vcGVucGdwanMub3JndTNZN8Cbcew9drl7N2ZwbRYhBIbMQsWPSHGuSTZrRisu
+8DPVUxLVAADnEFqybgsCcvHl4Yvq6EcjEQhAyunsX3678OQj0WnY+C5pzLR5kq4w
+7QXbx4B6oR2z+RtD7X+LSrxbgNmD/9VjBmhORQhjcRW8+kFe3wZlNZOipQgYX8vL
+lquniUkLRAhQ4uz/aLafvK04cv6r10G6ERc2AA==
+=UNFd
+-----END PGP SIGNATURE-----`},
+}
+
+func TestVerifyEdDSA(t *testing.T) {
+	for _, fixture := range eddsaSignatures {
+		keys, err := openpgp.ReadArmoredKeyRing(strings.NewReader(fixture.key))
+		if err != nil {
+			t.Fatalf("%s: %v", fixture.name, err)
+		}
+		issuers, err := ReadIssuers([]byte(fixture.signature))
+		if err != nil {
+			t.Fatalf("%s: %v", fixture.name, err)
+		}
+		if len(issuers) != 1 || issuers[0].KeyID != keys[0].PrimaryKey.KeyId ||
+			fmt.Sprintf("%X", issuers[0].Fingerprint) != fixture.fingerprint {
+			t.Errorf("%s: unexpected issuers: %+v", fixture.name, issuers)
+		}
+		for content, verdict := range map[string]Verdict{"hello\n": Good, "tampered\n": Bad} {
+			verifications, err := Verify(strings.NewReader(content), []byte(fixture.signature), keys)
+			if err != nil {
+				t.Fatalf("%s: %v", fixture.name, err)
+			}
+			if len(verifications) != 1 || verifications[0].Verdict != verdict {
+				t.Errorf("%s: expected %v for %q, got: %+v", fixture.name, verdict, content, verifications)
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+/* SPDX-License-Identifier: GPL-3.0-only */
+
+package signature
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	gocryptopacket "golang.org/x/crypto/openpgp/packet"
+)
+
+// Verdict is the result of verifying a signature.
+type Verdict uint
+
+const (
+	// Good indicates a valid signature by a key from the keyring.
+	Good Verdict = iota
+	// Bad indicates that the signature is not valid for any key from the keyring with the issuer's
+	// key-id.
+	Bad
+	// UnknownKey indicates that the keyring has no key with the issuer's key-id.
+	UnknownKey
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Good:
+		return "good"
+	case Bad:
+		return "bad"
+	case UnknownKey:
+		return "unknown key"
+	default:
+		panic(fmt.Sprintf("BUG: unknown verdict %d", uint(v)))
+	}
+}
+
+// Verification is the result of verifying a single signature. Signer is the key that made a good
+// signature or, for a bad signature, the (first) key with the issuer's key-id. Err describes why a
+// signature is bad.
+type Verification struct {
+	Issuer  Issuer
+	Verdict Verdict
+	Signer  *openpgp.Entity
+	Err     error
+}
+
+// Verify verifies every signature in an armored signature block over the artifact's content, using
+// the keys, including subkeys, in the keyring. Only the cryptographic validity of the signature is
+// verified, i.e. revocation or expiration of the key is not considered. io.EOF is returned if
+// content is empty, i.e. there is no signature.
+func Verify(artifact io.ReadSeeker, content []byte, keys openpgp.EntityList) ([]Verification, error) {
+	signatures, legacySignatures, err := readSignatures(content)
+	if err != nil {
+		return nil, err
+	}
+	var verifications []Verification
+	for _, sig := range signatures {
+		verification := Verification{Issuer: Issuer{KeyID: *sig.IssuerKeyId, Fingerprint: sig.IssuerFingerprint}}
+		candidates := keys.KeysById(*sig.IssuerKeyId)
+		if len(candidates) == 0 {
+			verification.Verdict = UnknownKey
+			verifications = append(verifications, verification)
+			continue
+		}
+		verification.Verdict, verification.Signer = Bad, candidates[0].Entity
+		for _, candidate := range candidates {
+			var h hash.Hash
+			if h, err = sig.PrepareVerify(); err != nil {
+				verification.Err = err
+				break
+			}
+			if err = hashArtifact(artifact, h, sig.SigType == packet.SigTypeText); err != nil {
+				return nil, err
+			}
+			if verification.Err = candidate.PublicKey.VerifySignature(h, sig); verification.Err == nil {
+				verification.Verdict, verification.Signer = Good, candidate.Entity
+				break
+			}
+		}
+		verifications = append(verifications, verification)
+	}
+	for _, sig := range legacySignatures {
+		verification, err := verifyLegacy(artifact, sig, keys)
+		if err != nil {
+			return nil, err
+		}
+		verifications = append(verifications, verification)
+	}
+	return verifications, nil
+}
+
+// verifyLegacy verifies a SignatureV3 using golang.org/x/crypto, as ProtonMail/go-crypto does not
+// support this legacy format. The public key is converted by re-parsing its serialized packet.
+func verifyLegacy(artifact io.ReadSeeker, sig *gocryptopacket.SignatureV3, keys openpgp.EntityList) (Verification, error) {
+	verification := Verification{Issuer: Issuer{KeyID: sig.IssuerKeyId}}
+	candidates := keys.KeysById(sig.IssuerKeyId)
+	if len(candidates) == 0 {
+		verification.Verdict = UnknownKey
+		return verification, nil
+	}
+	verification.Verdict, verification.Signer = Bad, candidates[0].Entity
+	for _, candidate := range candidates {
+		var serialized bytes.Buffer
+		if verification.Err = candidate.PublicKey.Serialize(&serialized); verification.Err != nil {
+			continue
+		}
+		pkt, err := gocryptopacket.Read(&serialized)
+		if err != nil {
+			verification.Err = err
+			continue
+		}
+		var publicKey *gocryptopacket.PublicKey
+		switch key := pkt.(type) {
+		case *gocryptopacket.PublicKey:
+			publicKey = key
+		default:
+			verification.Err = errors.New("public key unsupported for legacy signature")
+			continue
+		}
+		if !sig.Hash.Available() {
+			verification.Err = errors.New("hash function unavailable for legacy signature")
+			break
+		}
+		h := sig.Hash.New()
+		if err = hashArtifact(artifact, h, sig.SigType == gocryptopacket.SigTypeText); err != nil {
+			return verification, err
+		}
+		if verification.Err = publicKey.VerifySignatureV3(h, sig); verification.Err == nil {
+			verification.Verdict, verification.Signer = Good, candidate.Entity
+			break
+		}
+	}
+	return verification, nil
+}
+
+// hashArtifact writes the artifact's content, from the start, to the hash. Text signatures are
+// computed over the canonicalized line endings.
+func hashArtifact(artifact io.ReadSeeker, h hash.Hash, text bool) error {
+	if _, err := artifact.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	var w io.Writer = h
+	if text {
+		w = openpgp.NewCanonicalTextHash(h)
+	}
+	_, err := io.Copy(w, artifact)
+	return err
+}